@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/demo"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// printDemoIssues writes the issues the demo created in the in-memory repository to w
+func printDemoIssues(w io.Writer, issues []models.GitHubIssue) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "\n=== Issues in %s/%s ===\n", demo.Owner, demo.Repository)
+	fmt.Fprintln(tw, "ISSUE\tWORK ITEM\tSTATE\tCOMMENTS\tASSIGNEES\tTITLE\tLABELS")
+	for _, issue := range issues {
+		fmt.Fprintf(tw, "#%d\t%d\t%s\t%d\t%s\t%s\t%s\n",
+			issue.Number, issue.SourceWIID, issue.State, len(issue.Comments),
+			strings.Join(issue.Assignees, ", "), issue.Title, strings.Join(issue.Labels, ", "))
+	}
+
+	tw.Flush()
+}
+
+// printDemoFiles lists the files the demo wrote and how to inspect them
+func printDemoFiles(w io.Writer, cfg *config.Config) {
+	fmt.Fprintln(w, "\n=== Demo Files ===")
+	fmt.Fprintf(w, "Dataset:     %s\n", cfg.Migration.DatasetPath)
+	fmt.Fprintf(w, "Checkpoint:  %s\n", cfg.Migration.CheckpointPath)
+	fmt.Fprintf(w, "Reports:     %s\n", cfg.Reports.Directory)
+	fmt.Fprintln(w, "\nInspect them with:")
+	fmt.Fprintf(w, "  adowi2gh status --checkpoint %s\n", cfg.Migration.CheckpointPath)
+	fmt.Fprintf(w, "  adowi2gh reports list --dir %s\n", cfg.Reports.Directory)
+}
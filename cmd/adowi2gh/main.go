@@ -1,21 +1,33 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/jlucaspains/adowi2gh/internal/ado"
+	"github.com/jlucaspains/adowi2gh/internal/audit"
 	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/demo"
 	"github.com/jlucaspains/adowi2gh/internal/github"
+	"github.com/jlucaspains/adowi2gh/internal/lock"
 	"github.com/jlucaspains/adowi2gh/internal/migration"
 	"github.com/jlucaspains/adowi2gh/internal/models"
+	"github.com/jlucaspains/adowi2gh/internal/notify"
+	"github.com/jlucaspains/adowi2gh/internal/reports"
 )
 
 var (
@@ -26,15 +38,40 @@ var (
 	resume     bool
 	batchSize  int
 	reportFile string
+	exportPath string
+	runLabel   string
+	reportsDir string
+	quiet      bool
+	retryFail  bool
+	idRange    string
+	timeBudget time.Duration
+	fetchOnly  bool
+	publish    bool
+	dataset    string
+	traceMap   bool
+	checkpoint string
+	demoDir    string
 )
 
+// exitCodeTimeBudget signals a run that stopped cleanly because its time budget ran out
+const exitCodeTimeBudget = 3
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
+// exitCode maps a command error to the process exit code
+func exitCode(err error) int {
+	if errors.Is(err, migration.ErrTimeBudgetExceeded) {
+		return exitCodeTimeBudget
+	}
+
+	return 1
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "adowi2gh",
 	Short: "Migrate work items from Azure DevOps to GitHub issues",
@@ -81,6 +118,49 @@ var validateCmd = &cobra.Command{
 	RunE:  validateConfig,
 }
 
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export work items to an offline archive",
+	Long: `Export work items and their comments from Azure DevOps to a newline-delimited
+JSON archive, one record per work item.
+
+Use a .gz or .zst extension to compress the archive with gzip or zstd. Use
+--resume to continue an interrupted export; items already in the archive are
+skipped.`,
+	RunE: runExport,
+}
+
+var reportsCmd = &cobra.Command{
+	Use:   "reports",
+	Short: "Migration report management commands",
+	Long:  "Commands for managing the migration reports kept in the reports directory.",
+}
+
+var reportsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List migration reports",
+	Long:  "List the migration reports in the reports directory, newest first.",
+	RunE:  listReports,
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration progress",
+	Long:  "Show the progress of the migration recorded in the checkpoint, including counts, timing and rate limit waits.",
+	RunE:  showStatus,
+}
+
+var demoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Run a sample migration locally",
+	Long: `Run a dry run and a migration of bundled sample work items against in-memory
+stand-ins for Azure DevOps and GitHub. No tokens or network access are needed.
+
+The dataset, checkpoint and reports are written to --dir so the output formats
+can be inspected, e.g. with the status and reports list commands.`,
+	RunE: runDemo,
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
@@ -103,15 +183,42 @@ func init() {
 	// Migrate command flags
 	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview migration without making changes")
 	migrateCmd.Flags().BoolVar(&resume, "resume", false, "Resume from last checkpoint")
+	migrateCmd.Flags().BoolVar(&retryFail, "retry-failed", false, "Only retry work items in the checkpoint retry queue")
 	migrateCmd.Flags().IntVar(&batchSize, "batch-size", 0, "Number of items to process in each batch (0 = use config)")
 	migrateCmd.Flags().StringVar(&reportFile, "report", "", "Output file for migration report")
+	migrateCmd.Flags().StringVar(&runLabel, "run-label", "", "Label applied to every issue created by this run (overrides config)")
+	migrateCmd.Flags().BoolVar(&fetchOnly, "fetch-only", false, "Only fetch work items from Azure DevOps into the dataset")
+	migrateCmd.Flags().BoolVar(&publish, "publish-only", false, "Only publish a previously fetched dataset to GitHub")
+	migrateCmd.Flags().StringVar(&dataset, "dataset", "", "Dataset path shared by the fetch and publish phases (overrides dataset_path)")
+	migrateCmd.Flags().DurationVar(&timeBudget, "time-budget", 0, "Stop cleanly after this long, e.g. 45m (overrides max_run_duration)")
+	migrateCmd.Flags().StringVar(&idRange, "id-range", "", "Only migrate work items with IDs in this range (e.g. 1000-2000)")
+	migrateCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Log the summary instead of printing the summary table")
+	migrateCmd.Flags().BoolVar(&traceMap, "trace-mapping", false, "Include the rule behind each mapping decision in the dry run report")
+
+	// Export command flags
+	exportCmd.Flags().StringVarP(&exportPath, "output", "o", "./exports/work_items.ndjson.gz", "Archive file path (.gz for gzip, .zst for zstd compression)")
+	exportCmd.Flags().BoolVar(&resume, "resume", false, "Append to an existing archive, skipping exported items")
+
+	// Reports command flags
+	reportsListCmd.Flags().StringVar(&reportsDir, "dir", "", "Reports directory (default: reports.directory from config)")
+
+	// Status command flags
+	statusCmd.Flags().StringVar(&checkpoint, "checkpoint", "", "Checkpoint file (default: migration.checkpoint_path from config)")
+
+	// Demo command flags
+	demoCmd.Flags().StringVar(&demoDir, "dir", "", "Directory for the demo files (default: a new temporary directory)")
 
 	// Add subcommands
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(reportsCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(demoCmd)
 	rootCmd.AddCommand(versionCmd)
 	configCmd.AddCommand(configInitCmd)
+	reportsCmd.AddCommand(reportsListCmd)
 }
 
 func runMigration(cmd *cobra.Command, args []string) error {
@@ -131,9 +238,49 @@ func runMigration(cmd *cobra.Command, args []string) error {
 	if resume {
 		cfg.Migration.ResumeFromCheckpoint = true
 	}
+	if retryFail {
+		cfg.Migration.RetryFailed = true
+	}
 	if batchSize > 0 {
 		cfg.Migration.BatchSize = batchSize
 	}
+	if runLabel != "" {
+		cfg.Migration.FieldMapping.RunLabel = runLabel
+	}
+	if traceMap {
+		cfg.Migration.TraceMapping = true
+	}
+	if fetchOnly {
+		cfg.Migration.FetchOnly = true
+	}
+	if publish {
+		cfg.Migration.PublishOnly = true
+	}
+	if cfg.Migration.FetchOnly && cfg.Migration.PublishOnly {
+		return fmt.Errorf("--fetch-only and --publish-only cannot be combined")
+	}
+	if dataset != "" {
+		cfg.Migration.DatasetPath = dataset
+	}
+	if timeBudget > 0 {
+		cfg.Migration.MaxRunDuration = timeBudget
+	}
+	if idRange != "" {
+		minID, maxID, err := config.ParseIDRange(idRange)
+		if err != nil {
+			return err
+		}
+		cfg.AzureDevOps.Query.MinID = minID
+		cfg.AzureDevOps.Query.MaxID = maxID
+
+		// Each range keeps its own checkpoint, run lock and dataset, so range runs sharing a
+		// configuration can go in parallel and resume independently
+		cfg.Migration.CheckpointPath = cfg.AzureDevOps.Query.RangePath(cfg.Migration.CheckpointPath)
+		if dataset == "" {
+			cfg.Migration.DatasetPath = cfg.AzureDevOps.Query.RangePath(cfg.Migration.DatasetPath)
+		}
+		logger.Info("Migrating an ID range", "range", idRange, "checkpoint", cfg.Migration.CheckpointPath, "dataset", cfg.Migration.DatasetPath)
+	}
 	logger.Info("Starting Azure DevOps to GitHub migration...")
 	logger.Info("Azure DevOps", "url", cfg.AzureDevOps.OrganizationURL+"/"+cfg.AzureDevOps.Project)
 	logger.Info("GitHub", "repo", cfg.GitHub.Owner+"/"+cfg.GitHub.Repository)
@@ -152,6 +299,20 @@ func runMigration(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create GitHub client: %w", err)
 	}
 
+	if cfg.Reports.AuditLog != "" {
+		auditLog, err := audit.Open(cfg.Reports.AuditLog)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := auditLog.Close(); err != nil {
+				logger.Warn("Failed to close audit log", "error", err)
+			}
+		}()
+		githubClient.SetAuditLog(auditLog)
+		logger.Info("Auditing write operations", "file", cfg.Reports.AuditLog)
+	}
+
 	// Create mapper
 	mapper := migration.NewMapper(&cfg.Migration, logger)
 
@@ -171,23 +332,229 @@ func runMigration(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Run migration
+	// Run migration. A run that stopped early, e.g. out of time, without its target
+	// repository or after losing the run lock, still has a report worth saving.
 	report, err := engine.Run(ctx)
-	if err != nil {
+	stopErr := err
+	if err != nil && report == nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
 	// Save report
 	reportPath := reportFile
 	if reportPath == "" {
-		reportPath = fmt.Sprintf("./reports/migration_report_%s.json", report.StartTime.Format("20060102_150405"))
+		reportPath = filepath.Join(cfg.Reports.Directory, reports.FileName(cfg.Reports.RunName, report.StartTime, report.DryRun))
 	}
 	if err := engine.SaveReport(reportPath); err != nil {
 		logger.Warn("Failed to save report", "error", err)
+	} else if reportFile == "" {
+		pruneReports(&cfg.Reports, logger)
 	}
 
 	// Print summary
-	printMigrationSummary(report, logger)
+	if quiet || !isTerminal(os.Stdout) {
+		printMigrationSummary(report, logger)
+	} else {
+		printSummaryTable(os.Stdout, report)
+	}
+
+	if cfg.Notify.Email.SMTPHost != "" {
+		emailSummary(&cfg.Notify.Email, report, reportPath, stopErr, logger)
+	}
+
+	if errors.Is(stopErr, lock.ErrLocked) {
+		return fmt.Errorf("migration stopped, another run holds the checkpoint now: %w", stopErr)
+	}
+	if stopErr != nil {
+		return fmt.Errorf("migration stopped early, continue with --resume: %w", stopErr)
+	}
+
+	return nil
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	logger := setupLogger()
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	adoClient, err := ado.NewClient(&cfg.AzureDevOps, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create Azure DevOps client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		logger.Warn("Received interrupt signal, finishing current segment...")
+		cancel()
+	}()
+
+	exporter := migration.NewExporter(adoClient, &cfg.Migration, logger)
+	count, err := exporter.Export(ctx, exportPath, resume)
+	if err != nil {
+		return fmt.Errorf("export failed after %d work items: %w", count, err)
+	}
+
+	logger.Info("✓ Export completed", "work_items", count, "path", exportPath)
+	return nil
+}
+
+func pruneReports(cfg *config.ReportsConfig, logger *slog.Logger) {
+	removed, err := reports.Prune(cfg.Directory, cfg.RunName, cfg.Retention)
+	if err != nil {
+		logger.Warn("Failed to apply report retention", "error", err)
+		return
+	}
+
+	for _, path := range removed {
+		logger.Debug("Removed old report", "path", path)
+	}
+}
+
+// emailSummary sends the summary table with the report attached. A failed email is only
+// logged, the run itself already finished.
+func emailSummary(cfg *config.EmailConfig, report *models.MigrationReport, reportPath string, stopErr error, logger *slog.Logger) {
+	var body bytes.Buffer
+	if stopErr != nil {
+		fmt.Fprintf(&body, "The migration stopped early, continue with --resume: %v\n", stopErr)
+	}
+	printSummaryTable(&body, report)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Warn("Failed to email summary", "error", err)
+		return
+	}
+
+	subject := fmt.Sprintf("adowi2gh migration finished: %d successful, %d failed, %d skipped",
+		report.SuccessfulCount, report.FailedCount, report.SkippedCount)
+	if stopErr != nil {
+		subject = fmt.Sprintf("adowi2gh migration stopped early: %d successful, %d failed, %d skipped",
+			report.SuccessfulCount, report.FailedCount, report.SkippedCount)
+	}
+	if report.DryRun {
+		subject += " (dry run)"
+	}
+
+	attachment := notify.Attachment{Name: filepath.Base(reportPath), ContentType: "application/json", Data: data}
+	if err := notify.NewEmailNotifier(cfg).Send(subject, body.String(), attachment); err != nil {
+		logger.Warn("Failed to email summary", "error", err)
+		return
+	}
+
+	logger.Info("Emailed migration summary", "to", cfg.To)
+}
+
+func listReports(cmd *cobra.Command, args []string) error {
+	dir := reportsDir
+	if dir == "" {
+		dir = "./reports"
+		if cfg, err := config.LoadConfig(configFile); err == nil {
+			dir = cfg.Reports.Directory
+		}
+	}
+
+	entries, err := reports.List(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No reports found in %s\n", dir)
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tDATE\tMODE\tTOTAL\tSUCCESSFUL\tFAILED\tSKIPPED")
+	for _, entry := range entries {
+		if entry.Report == nil {
+			fmt.Fprintf(writer, "%s\t%s\t?\t-\t-\t-\t-\n", entry.Name, entry.ModTime.Format("2006-01-02 15:04"))
+			continue
+		}
+
+		mode := "migrate"
+		if entry.Report.DryRun {
+			mode = "dry-run"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			entry.Name,
+			entry.Report.StartTime.Format("2006-01-02 15:04"),
+			mode,
+			entry.Report.TotalWorkItems,
+			entry.Report.SuccessfulCount,
+			entry.Report.FailedCount,
+			entry.Report.SkippedCount)
+	}
+
+	return writer.Flush()
+}
+
+func showStatus(cmd *cobra.Command, args []string) error {
+	path := checkpoint
+	if path == "" {
+		path = "./migration_checkpoint.json"
+		if cfg, err := config.LoadConfig(configFile); err == nil && cfg.Migration.CheckpointPath != "" {
+			path = cfg.Migration.CheckpointPath
+		}
+	}
+
+	state, err := migration.LoadCheckpoint(path)
+	if err != nil {
+		return err
+	}
+
+	printStatus(os.Stdout, path, state)
+	return nil
+}
+
+func runDemo(cmd *cobra.Command, args []string) error {
+	logger := setupLogger()
+
+	dir := demoDir
+	if dir == "" {
+		var err error
+		dir, err = os.MkdirTemp("", "adowi2gh-demo-")
+		if err != nil {
+			return fmt.Errorf("failed to create demo directory: %w", err)
+		}
+	}
+
+	// The demo starts from the configuration config init writes
+	cfg := createDefaultConfig()
+	demo.Configure(cfg, dir)
+
+	source := demo.NewSource()
+	tracker := demo.NewTracker()
+	ctx := context.Background()
+
+	// Preview first, then migrate into the in-memory repository
+	for _, preview := range []bool{true, false} {
+		cfg.Migration.DryRun = preview
+		mapper := migration.NewMapper(&cfg.Migration, logger)
+		engine := migration.NewEngine(source, tracker, mapper, &cfg.Migration, logger)
+
+		report, err := engine.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("demo migration failed: %w", err)
+		}
+
+		reportPath := filepath.Join(cfg.Reports.Directory, reports.FileName(cfg.Reports.RunName, report.StartTime, report.DryRun))
+		if err := engine.SaveReport(reportPath); err != nil {
+			return fmt.Errorf("failed to save demo report: %w", err)
+		}
+
+		printSummaryTable(os.Stdout, report)
+	}
+
+	printDemoIssues(os.Stdout, tracker.Issues())
+	printDemoFiles(os.Stdout, cfg)
 
 	return nil
 }
@@ -214,6 +581,35 @@ func validateConfig(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("ado connection failed: %w", err)
 	}
 
+	// A stale area path silently matches nothing, so check them against the project tree
+	missing, err := adoClient.CheckAreaPaths(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check area paths: %w", err)
+	}
+	for _, areaPath := range missing {
+		if len(areaPath.Suggestions) > 0 {
+			logger.Error("Area path not found", "area_path", areaPath.Configured, "did_you_mean", strings.Join(areaPath.Suggestions, ", "))
+		} else {
+			logger.Error("Area path not found", "area_path", areaPath.Configured)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d configured area path(s) not found in project %s", len(missing), cfg.AzureDevOps.Project)
+	}
+
+	// Run the configured query so a broken WIQL is caught before a real run.
+	// A filter that is too wide shows up as an unexpected type or state in the breakdown.
+	summary, err := adoClient.SummarizeQuery(ctx)
+	if err != nil {
+		return fmt.Errorf("ado query failed: %w", err)
+	}
+	if summary.Matching == 0 {
+		logger.Warn("The configured query matches no work items", "excluded_by_type", summary.Excluded)
+	} else {
+		logger.Info("✓ Query executed successfully", "matching_work_items", summary.Matching, "excluded_by_type", summary.Excluded)
+		printTypeStates(os.Stdout, summary.TypeStates)
+	}
+
 	githubClient, err := github.NewClient(&cfg.GitHub, logger)
 	if err != nil {
 		return fmt.Errorf("failed to create GitHub client: %w", err)
@@ -274,7 +670,9 @@ func createDefaultConfig() *config.Config {
 				WIQL:          "",
 				WorkItemTypes: []string{"Bug", "User Story", "Task"},
 				States:        []string{"New", "Active", "Resolved"},
+				ExcludeTypes:  []string{"Test Case", "Test Suite", "Test Plan", "Shared Steps"},
 			},
+			MaxConcurrentRequests: 4,
 		},
 		GitHub: config.GitHubConfig{
 			Token:      "your-github-token",
@@ -306,12 +704,23 @@ func createDefaultConfig() *config.Config {
 				},
 				IncludeSeverityLabel: true,
 				IncludeAreaPathLabel: true,
+				IncludeProvenance:    true,
+				ProvenanceLabel:      "migrated-from-ado",
 				TimeZone:             "UTC",
 			},
 			UserMapping:          map[string]string{},
 			DryRun:               false,
 			IncludeComments:      true,
 			ResumeFromCheckpoint: false,
+			Concurrency:          1,
+			MaxRetryAttempts:     3,
+			CheckpointPath:       "./migration_checkpoint.json",
+			LockTTL:              15 * time.Minute,
+			DatasetPath:          "./migration_dataset.ndjson.gz",
+		},
+		Reports: config.ReportsConfig{
+			Directory: "./reports",
+			Retention: 10,
 		},
 	}
 }
@@ -337,7 +746,8 @@ func printMigrationSummary(report *models.MigrationReport, logger *slog.Logger)
 		"total", report.TotalWorkItems,
 		"successful", report.SuccessfulCount,
 		"failed", report.FailedCount,
-		"skipped", report.SkippedCount)
+		"skipped", report.SkippedCount,
+		"excluded", report.ExcludedCount)
 
 	if report.EndTime != nil {
 		duration := report.EndTime.Sub(report.StartTime)
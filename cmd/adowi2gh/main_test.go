@@ -0,0 +1,19 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jlucaspains/adowi2gh/internal/migration"
+)
+
+func TestExitCode(t *testing.T) {
+	budgetErr := fmt.Errorf("migration stopped early, continue with --resume: %w", fmt.Errorf("run exceeded 1h0m0s: %w", migration.ErrTimeBudgetExceeded))
+
+	assert.Equal(t, exitCodeTimeBudget, exitCode(budgetErr))
+	assert.Equal(t, 3, exitCode(budgetErr))
+	assert.Equal(t, 1, exitCode(errors.New("ado connection failed")))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/migration"
+)
+
+// printStatus writes the progress recorded in a checkpoint to w
+func printStatus(w io.Writer, path string, checkpoint *migration.MigrationCheckpoint) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	stats := checkpoint.Stats
+
+	fmt.Fprintln(tw, "\n=== Migration Status ===")
+	fmt.Fprintf(tw, "Checkpoint:\t%s\n", path)
+	if !checkpoint.StartTime.IsZero() {
+		fmt.Fprintf(tw, "Started:\t%s\n", checkpoint.StartTime.Format("2006-01-02 15:04:05"))
+	}
+	if !checkpoint.LastUpdate.IsZero() {
+		fmt.Fprintf(tw, "Last update:\t%s\n", checkpoint.LastUpdate.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Fprintf(tw, "Runs:\t%d\n", stats.Runs)
+	fmt.Fprintf(tw, "Batches:\t%d\n", stats.Batches)
+
+	migrated := len(checkpoint.ProcessedItems)
+	if stats.TotalWorkItems > 0 {
+		fmt.Fprintf(tw, "Progress:\t%d of %d migrated (%.0f%%)\n",
+			migrated, stats.TotalWorkItems, float64(migrated)*100/float64(stats.TotalWorkItems))
+	} else {
+		fmt.Fprintf(tw, "Progress:\t%d migrated\n", migrated)
+	}
+
+	if stats.RunWorkItems > 0 && stats.RunWorkItems != stats.TotalWorkItems {
+		fmt.Fprintf(tw, "Latest run:\t%d work items\n", stats.RunWorkItems)
+	}
+
+	if average := stats.AverageItemDuration(); average > 0 {
+		fmt.Fprintf(tw, "Average per item:\t%s\n", average.Round(time.Millisecond))
+		if remaining := stats.TotalWorkItems - migrated; remaining > 0 {
+			fmt.Fprintf(tw, "Estimated remaining:\t%s\n", (average * time.Duration(remaining)).Round(time.Second))
+		}
+	}
+	fmt.Fprintf(tw, "Rate limit waits:\t%d (%s)\n", stats.RateLimitWaits, stats.RateLimitWait.Round(time.Second))
+
+	pending, escalated := 0, 0
+	for _, entry := range checkpoint.RetryQueue {
+		if entry.Escalated {
+			escalated++
+		} else {
+			pending++
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "STATUS\tCOUNT")
+	fmt.Fprintf(tw, "Successful\t%d\n", stats.Successful)
+	fmt.Fprintf(tw, "Failed attempts\t%d\n", stats.Failed)
+	fmt.Fprintf(tw, "Skipped\t%d\n", stats.Skipped)
+	fmt.Fprintf(tw, "Pending retry\t%d\n", pending)
+	fmt.Fprintf(tw, "Escalated\t%d\n", escalated)
+
+	tw.Flush()
+}
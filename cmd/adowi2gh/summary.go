@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/ado"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// maxSummaryErrors caps the number of error messages listed in the summary table
+const maxSummaryErrors = 10
+
+type summaryCount struct {
+	Key   string
+	Count int
+}
+
+// isTerminal reports whether f is attached to an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// printSummaryTable writes a human readable end-of-run summary to w
+func printSummaryTable(w io.Writer, report *models.MigrationReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	title := "Migration Summary"
+	if report.DryRun {
+		title += " (dry run)"
+	}
+	fmt.Fprintf(tw, "\n=== %s ===\n", title)
+	if report.RunLabel != "" {
+		fmt.Fprintf(tw, "Run label:\t%s\n", report.RunLabel)
+	}
+
+	if report.EndTime != nil {
+		duration := report.EndTime.Sub(report.StartTime)
+		fmt.Fprintf(tw, "Duration:\t%s\n", duration.Round(time.Second))
+
+		processed := report.SuccessfulCount + report.FailedCount + report.SkippedCount
+		if processed > 0 {
+			fmt.Fprintf(tw, "Average per item:\t%s\n", (duration / time.Duration(processed)).Round(time.Millisecond))
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "STATUS\tCOUNT")
+	fmt.Fprintf(tw, "Total\t%d\n", report.TotalWorkItems)
+	fmt.Fprintf(tw, "Successful\t%d\n", report.SuccessfulCount)
+	fmt.Fprintf(tw, "Failed\t%d\n", report.FailedCount)
+	fmt.Fprintf(tw, "Skipped\t%d\n", report.SkippedCount)
+	if report.ExcludedCount > 0 {
+		fmt.Fprintf(tw, "Excluded (retention)\t%d\n", report.ExcludedCount)
+	}
+
+	categories := map[string]int{}
+	var errorMessages []string
+	for _, mapping := range report.Mappings {
+		if mapping.Status == "failed" {
+			category := mapping.ErrorCategory
+			if category == "" {
+				category = "other"
+			}
+			categories[category]++
+			message := fmt.Sprintf("#%d: %s", mapping.AdoWorkItemID, mapping.ErrorMessage)
+			if mapping.AdoWorkItemURL != "" {
+				message = fmt.Sprintf("#%d (%s): %s", mapping.AdoWorkItemID, mapping.AdoWorkItemURL, mapping.ErrorMessage)
+			}
+			errorMessages = append(errorMessages, message)
+		}
+	}
+
+	if report.Breakdown != nil && len(report.Breakdown.ByType) > 0 {
+		types := make([]string, 0, len(report.Breakdown.ByType))
+		for workItemType := range report.Breakdown.ByType {
+			types = append(types, workItemType)
+		}
+		sort.Strings(types)
+
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "TYPE\tSUCCESSFUL\tFAILED\tSKIPPED")
+		for _, workItemType := range types {
+			counts := report.Breakdown.ByType[workItemType]
+			name := workItemType
+			if name == "" {
+				name = "(unknown)"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, counts.Successful, counts.Failed, counts.Skipped)
+		}
+	}
+
+	if report.Breakdown != nil && len(report.Breakdown.ByState) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "TARGET STATE\tCOUNT")
+		for _, state := range sortCounts(report.Breakdown.ByState) {
+			fmt.Fprintf(tw, "%s\t%d\n", state.Key, state.Count)
+		}
+	}
+
+	if report.Breakdown != nil && len(report.Breakdown.ByRejectedAssignee) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "REJECTED ASSIGNEE\tITEMS")
+		for _, assignee := range sortCounts(report.Breakdown.ByRejectedAssignee) {
+			fmt.Fprintf(tw, "%s\t%d\n", assignee.Key, assignee.Count)
+		}
+	}
+
+	if len(report.GitHubRequests) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "GITHUB REQUESTS\tCOUNT")
+		for _, category := range sortCounts(report.GitHubRequests) {
+			fmt.Fprintf(tw, "%s\t%d\n", category.Key, category.Count)
+		}
+	}
+
+	if len(categories) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "ERROR CATEGORY\tCOUNT")
+		for _, category := range sortCounts(categories) {
+			fmt.Fprintf(tw, "%s\t%d\n", category.Key, category.Count)
+		}
+	}
+
+	// Real runs record errors on the report; dry runs only have them on the mappings
+	if len(report.Errors) > 0 {
+		errorMessages = report.Errors
+	}
+	if len(errorMessages) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Errors:")
+		for i, message := range errorMessages {
+			if i == maxSummaryErrors {
+				fmt.Fprintf(tw, "  ... and %d more (see the report file)\n", len(errorMessages)-maxSummaryErrors)
+				break
+			}
+			fmt.Fprintf(tw, "  %s\n", message)
+		}
+	}
+
+	tw.Flush()
+}
+
+// sortCounts orders counts descending, breaking ties by key
+func sortCounts(counts map[string]int) []summaryCount {
+	sorted := make([]summaryCount, 0, len(counts))
+	for key, count := range counts {
+		sorted = append(sorted, summaryCount{Key: key, Count: count})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	return sorted
+}
+
+// printTypeStates writes the matching work items grouped by type and state, with a total per type
+func printTypeStates(w io.Writer, counts []ado.TypeStateCount) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	totals := map[string]int{}
+	for _, count := range counts {
+		totals[count.Type] += count.Count
+	}
+
+	fmt.Fprintln(tw, "\n=== Matching Work Items ===")
+	fmt.Fprintln(tw, "TYPE\tITEMS")
+	for _, total := range sortCounts(totals) {
+		fmt.Fprintf(tw, "%s\t%d\n", total.Key, total.Count)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TYPE\tSTATE\tITEMS")
+	for _, count := range counts {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", count.Type, count.State, count.Count)
+	}
+
+	tw.Flush()
+}
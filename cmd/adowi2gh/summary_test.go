@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestPrintSummaryTable(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+
+	migrated := &models.MigrationReport{
+		RunLabel:        "wave-1",
+		StartTime:       start,
+		EndTime:         &end,
+		TotalWorkItems:  6,
+		SuccessfulCount: 3,
+		FailedCount:     2,
+		SkippedCount:    1,
+		ExcludedCount:   4,
+		GitHubRequests:  map[string]int{"rest": 12, "search": 3, "graphql": 3},
+		Mappings: []models.MigrationMapping{
+			{AdoWorkItemID: 1, AdoWorkItemType: "Bug", Status: "success", TargetState: "open", Labels: []string{"bug"}},
+			{AdoWorkItemID: 2, AdoWorkItemType: "Bug", Status: "success", TargetState: "closed", Labels: []string{"bug"}},
+			{AdoWorkItemID: 3, AdoWorkItemType: "User Story", Status: "success", TargetState: "open"},
+			{AdoWorkItemID: 4, AdoWorkItemType: "User Story", Status: "failed", ErrorCategory: "validation", ErrorMessage: "422 Validation Failed"},
+			{AdoWorkItemID: 5, AdoWorkItemType: "Task", Status: "failed", ErrorMessage: "boom"},
+			{AdoWorkItemID: 6, AdoWorkItemType: "Task", Status: "skipped"},
+		},
+		Errors: []string{"Failed to migrate work item 4: 422 Validation Failed", "Failed to migrate work item 5: boom"},
+	}
+	migrated.ComputeBreakdown()
+
+	dryRun := &models.MigrationReport{
+		DryRun:         true,
+		StartTime:      start,
+		EndTime:        &end,
+		TotalWorkItems: 12,
+		FailedCount:    12,
+	}
+	for id := 1; id <= 12; id++ {
+		dryRun.Mappings = append(dryRun.Mappings, models.MigrationMapping{
+			AdoWorkItemID:     id,
+			AdoWorkItemType:   "Bug",
+			AdoWorkItemURL:    fmt.Sprintf("https://dev.azure.com/org/project/_workitems/edit/%d", id),
+			Status:            "failed",
+			ErrorCategory:     "mapping",
+			ErrorMessage:      "title is empty",
+			RejectedAssignees: []string{"cpark"},
+		})
+	}
+	dryRun.ComputeBreakdown()
+
+	tests := []struct {
+		name   string
+		report *models.MigrationReport
+	}{
+		{name: "migration", report: migrated},
+		{name: "dry_run", report: dryRun},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printSummaryTable(&out, tt.report)
+
+			golden := filepath.Join("testdata", "summary_"+tt.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, out.Bytes(), 0644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), out.String())
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package ado
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+)
+
+// classificationDepth is how many levels of the area and iteration trees are fetched
+const classificationDepth = 20
+
+// maxAreaPathSuggestions caps the close matches suggested for a missing area path
+const maxAreaPathSuggestions = 3
+
+// MissingAreaPath is a configured area path that doesn't exist in the project
+type MissingAreaPath struct {
+	Configured  string
+	Suggestions []string // Closest existing area paths, best match first
+}
+
+// GetAreaPaths returns every area path of the project as used in WIQL, e.g. "Project\Team A"
+func (c *Client) GetAreaPaths(ctx context.Context) ([]string, error) {
+	root, err := c.getClassificationTree(ctx, workitemtracking.TreeStructureGroupValues.Areas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get area paths: %w", err)
+	}
+
+	var paths []string
+	var walk func(node workitemtracking.WorkItemClassificationNode, parent string)
+	walk = func(node workitemtracking.WorkItemClassificationNode, parent string) {
+		path := getStringPtr(node.Name)
+		if parent != "" {
+			path = parent + `\` + path
+		}
+		paths = append(paths, path)
+
+		if node.Children != nil {
+			for _, child := range *node.Children {
+				walk(child, path)
+			}
+		}
+	}
+	walk(*root, "")
+
+	return paths, nil
+}
+
+// getClassificationTree fetches the area or iteration tree of the project
+func (c *Client) getClassificationTree(ctx context.Context, structureGroup workitemtracking.TreeStructureGroup) (*workitemtracking.WorkItemClassificationNode, error) {
+	depth := classificationDepth
+
+	return c.witClient.GetClassificationNode(ctx, workitemtracking.GetClassificationNodeArgs{
+		Project:        &c.config.Project,
+		StructureGroup: &structureGroup,
+		Depth:          &depth,
+	})
+}
+
+// resolveAreaPaths normalizes the configured area paths against the project area tree.
+// Paths that don't match the tree are kept in their best-effort normalized form.
+func (c *Client) resolveAreaPaths(ctx context.Context) ([]string, error) {
+	if len(c.config.Query.AreaPaths) == 0 {
+		return nil, nil
+	}
+
+	known, err := c.GetAreaPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]string, 0, len(c.config.Query.AreaPaths))
+	for _, areaPath := range c.config.Query.AreaPaths {
+		normalized, found := normalizeAreaPath(areaPath, c.config.Project, known)
+		if !found {
+			c.logger.Warn("Area path not found in project", "area_path", areaPath, "normalized", normalized)
+		} else if normalized != areaPath {
+			c.logger.Debug("Normalized area path", "configured", areaPath, "normalized", normalized)
+		}
+		resolved = append(resolved, normalized)
+	}
+
+	return resolved, nil
+}
+
+// CheckAreaPaths verifies every configured area path exists in the project area tree
+// and returns the ones that don't, with close matches to suggest.
+func (c *Client) CheckAreaPaths(ctx context.Context) ([]MissingAreaPath, error) {
+	if len(c.config.Query.AreaPaths) == 0 {
+		return nil, nil
+	}
+
+	known, err := c.GetAreaPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []MissingAreaPath
+	for _, areaPath := range c.config.Query.AreaPaths {
+		normalized, found := normalizeAreaPath(areaPath, c.config.Project, known)
+		if found {
+			continue
+		}
+
+		missing = append(missing, MissingAreaPath{
+			Configured:  areaPath,
+			Suggestions: closestAreaPaths(normalized, known, maxAreaPathSuggestions),
+		})
+	}
+
+	return missing, nil
+}
+
+// closestAreaPaths returns up to limit known paths within a small edit distance of path,
+// closest first. Distances are compared case-insensitively.
+func closestAreaPaths(path string, known []string, limit int) []string {
+	type match struct {
+		path     string
+		distance int
+	}
+
+	target := strings.ToLower(path)
+	// Allow roughly one typo per four characters of the last segment, at least two
+	segments := areaPathSegments(path)
+	threshold := 2
+	if len(segments) > 0 {
+		threshold = max(threshold, len([]rune(segments[len(segments)-1]))/4)
+	}
+
+	var matches []match
+	for _, candidate := range known {
+		distance := levenshtein(target, strings.ToLower(candidate))
+		if distance <= threshold {
+			matches = append(matches, match{path: candidate, distance: distance})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	suggestions := make([]string, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].path)
+	}
+
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// normalizeAreaPath converts a configured area path to the "Project\Area\Sub" form WIQL
+// expects. The leading backslash, the project prefix and the "Area" node that appears
+// in classification paths are all optional, and forward slashes are accepted.
+// The returned path uses the casing of the matching entry in known; found is false
+// when nothing in known matches.
+func normalizeAreaPath(configured, project string, known []string) (string, bool) {
+	segments := areaPathSegments(configured)
+	if len(segments) > 0 && strings.EqualFold(segments[0], project) {
+		segments = segments[1:]
+	}
+
+	candidates := [][]string{segments}
+	// Classification paths read "\Project\Area\Sub", unless the project has an area named "Area"
+	if len(segments) > 0 && strings.EqualFold(segments[0], "Area") {
+		candidates = append(candidates, segments[1:])
+	}
+
+	byKey := make(map[string]string, len(known))
+	for _, path := range known {
+		byKey[strings.ToLower(path)] = path
+	}
+
+	for _, candidate := range candidates {
+		path := strings.Join(append([]string{project}, candidate...), `\`)
+		if match, ok := byKey[strings.ToLower(path)]; ok {
+			return match, true
+		}
+	}
+
+	return strings.Join(append([]string{project}, candidates[len(candidates)-1]...), `\`), false
+}
+
+// areaPathSegments splits a path on backslashes or slashes, dropping empty segments
+func areaPathSegments(path string) []string {
+	return strings.FieldsFunc(strings.TrimSpace(path), func(r rune) bool {
+		return r == '\\' || r == '/'
+	})
+}
@@ -2,21 +2,39 @@ package ado
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/core"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
 	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
 
 	"github.com/jlucaspains/adowi2gh/internal/config"
 	"github.com/jlucaspains/adowi2gh/internal/models"
 )
 
+const maxThrottleRetries = 5
+
+// throttleBaseDelay is the first backoff after ADO throttles a request, doubled on each retry
+var throttleBaseDelay = 2 * time.Second
+
 type Client struct {
-	connection *azuredevops.Connection
-	witClient  workitemtracking.Client
-	config     *config.AzureDevOpsConfig
-	logger     *slog.Logger
+	connection     *azuredevops.Connection
+	coreClient     core.Client
+	identityClient identity.Client
+	witClient      workitemtracking.Client
+	config         *config.AzureDevOpsConfig
+	logger         *slog.Logger
+	retainedFields []string
+	observeWait    func(time.Duration) // Called with every backoff spent on throttling
+	identityMu     sync.Mutex
+	identities     map[string]*models.User // Resolved identities by lowercased descriptor, nil for misses
 }
 
 func NewClient(cfg *config.AzureDevOpsConfig, logger *slog.Logger) (*Client, error) {
@@ -31,6 +49,16 @@ func NewClient(cfg *config.AzureDevOpsConfig, logger *slog.Logger) (*Client, err
 	// Create a connection to Azure DevOps
 	connection := azuredevops.NewPatConnection(cfg.OrganizationURL, cfg.PersonalAccessToken)
 
+	coreClient, err := core.NewClient(context.Background(), connection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create core client: %w", err)
+	}
+
+	identityClient, err := identity.NewClient(context.Background(), connection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity client: %w", err)
+	}
+
 	// Create work item tracking client
 	witClient, err := workitemtracking.NewClient(context.Background(), connection)
 	if err != nil {
@@ -38,18 +66,39 @@ func NewClient(cfg *config.AzureDevOpsConfig, logger *slog.Logger) (*Client, err
 	}
 
 	return &Client{
-		connection: connection,
-		witClient:  witClient,
-		config:     cfg,
-		logger:     logger,
+		connection:     connection,
+		coreClient:     coreClient,
+		identityClient: identityClient,
+		witClient:      witClient,
+		config:         cfg,
+		logger:         logger,
+		identities:     map[string]*models.User{},
 	}, nil
 }
 
+// SetFieldProjection limits the fields kept on retrieved work items to the given
+// reference names. Everything else is discarded right after conversion.
+// A nil slice keeps all fields.
+func (c *Client) SetFieldProjection(fields []string) {
+	c.retainedFields = fields
+}
+
+// SetRateLimitObserver calls observe with every backoff spent while Azure DevOps
+// throttles requests. A nil observer disables it.
+func (c *Client) SetRateLimitObserver(observe func(time.Duration)) {
+	c.observeWait = observe
+}
+
 func (c *Client) TestConnection(ctx context.Context) error {
 	c.logger.Info("Testing Azure DevOps connection...")
 
+	// A missing project would otherwise surface as a confusing WIQL error
+	if err := c.checkProject(ctx); err != nil {
+		return err
+	}
+
 	// Try to execute a simple WIQL query to test the connection
-	testQuery := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = '%s'", c.config.Project)
+	testQuery := "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = " + wiqlLiteral(c.config.Project)
 
 	queryArgs := workitemtracking.QueryByWiqlArgs{
 		Project: &c.config.Project,
@@ -67,9 +116,68 @@ func (c *Client) TestConnection(ctx context.Context) error {
 	return nil
 }
 
+// checkProject verifies the configured project exists and the token can see it
+func (c *Client) checkProject(ctx context.Context) error {
+	_, err := c.coreClient.GetProject(ctx, core.GetProjectArgs{ProjectId: &c.config.Project})
+	if err != nil {
+		if statusCode(err) == http.StatusNotFound {
+			return fmt.Errorf("project %q not found in %s", c.config.Project, c.config.OrganizationURL)
+		}
+		return fmt.Errorf("failed to get project %q: %w", c.config.Project, err)
+	}
+
+	return nil
+}
+
 func (c *Client) GetWorkItems(ctx context.Context) ([]*models.WorkItem, error) {
+	workItemIds, err := c.QueryWorkItemIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workItems := []*models.WorkItem{}
+	err = c.StreamWorkItems(ctx, workItemIds, func(batch []*models.WorkItem) error {
+		workItems = append(workItems, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workItems, nil
+}
+
+// QueryWorkItemIDs runs the configured query and returns the matching work item IDs
+// in query order. Type exclusions need details and are applied by StreamWorkItems.
+func (c *Client) QueryWorkItemIDs(ctx context.Context) ([]int, error) {
 	c.logger.Info("Retrieving work items from Azure DevOps...")
 
+	workItemIds, err := c.queryWorkItemIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(workItemIds) == 0 {
+		c.logger.Warn("No work items found matching the query")
+	} else {
+		c.logger.Info("Found work items", "count", len(workItemIds))
+	}
+
+	return workItemIds, nil
+}
+
+// StreamWorkItems retrieves the details of workItemIds one batch at a time and passes
+// each batch to handle in the order of workItemIds, so callers never hold more than a
+// few batches in memory. Excluded types are left out of the batches.
+func (c *Client) StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
+	return c.getWorkItemDetails(ctx, workItemIds, func(workItems []*models.WorkItem) error {
+		// The default query already excludes types, user WIQL and ID lists may not
+		return handle(c.filterExcludedTypes(workItems))
+	})
+}
+
+// queryWorkItemIDs resolves the configured query to work item IDs
+func (c *Client) queryWorkItemIDs(ctx context.Context) ([]int, error) {
 	var workItemIds []int
 	var err error
 
@@ -84,22 +192,46 @@ func (c *Client) GetWorkItems(ctx context.Context) ([]*models.WorkItem, error) {
 		}
 	} else {
 		// Build a default query based on filters
-		wiql := c.buildDefaultQuery()
+		areaPaths, err := c.resolveAreaPaths(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		wiql := c.buildDefaultQuery(areaPaths)
 		workItemIds, err = c.executeWIQL(ctx, wiql)
 		if err != nil {
 			return nil, fmt.Errorf("failed to execute default query: %w", err)
 		}
 	}
 
-	if len(workItemIds) == 0 {
-		c.logger.Warn("No work items found matching the query")
-		return []*models.WorkItem{}, nil
+	// WIQL and ID lists come from the user, so the ID range is enforced here too
+	return c.filterIDRange(workItemIds), nil
+}
+
+func (c *Client) filterExcludedTypes(workItems []*models.WorkItem) []*models.WorkItem {
+	if len(c.config.Query.ExcludeTypes) == 0 {
+		return workItems
+	}
+
+	excluded := make(map[string]bool, len(c.config.Query.ExcludeTypes))
+	for _, wiType := range c.config.Query.ExcludeTypes {
+		excluded[strings.ToLower(wiType)] = true
 	}
 
-	c.logger.Info("Found work items, retrieving details", "count", len(workItemIds))
+	filtered := make([]*models.WorkItem, 0, len(workItems))
+	for _, workItem := range workItems {
+		if excluded[strings.ToLower(workItem.GetWorkItemType())] {
+			c.logger.Debug("Excluding work item by type", "id", workItem.ID, "type", workItem.GetWorkItemType())
+			continue
+		}
+		filtered = append(filtered, workItem)
+	}
+
+	if removed := len(workItems) - len(filtered); removed > 0 {
+		c.logger.Info("Excluded work items by type", "excluded", removed, "types", c.config.Query.ExcludeTypes)
+	}
 
-	// Get work item details
-	return c.getWorkItemDetails(ctx, workItemIds)
+	return filtered
 }
 
 func (c *Client) executeWIQL(ctx context.Context, wiql string) ([]int, error) {
@@ -127,68 +259,237 @@ func (c *Client) executeWIQL(ctx context.Context, wiql string) ([]int, error) {
 	return workItemIds, nil
 }
 
-func (c *Client) buildDefaultQuery() string {
-	query := fmt.Sprintf("SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = '%s'", c.config.Project)
+// buildDefaultQuery builds the WIQL for the configured filters. areaPaths are the
+// configured area paths already normalized against the project area tree.
+func (c *Client) buildDefaultQuery(areaPaths []string) string {
+	query := "SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = " + wiqlLiteral(c.config.Project)
 
 	if len(c.config.Query.WorkItemTypes) > 0 {
-		query += " AND [System.WorkItemType] IN ("
-		for i, wiType := range c.config.Query.WorkItemTypes {
-			if i > 0 {
-				query += ", "
-			}
-			query += fmt.Sprintf("'%s'", wiType)
-		}
-		query += ")"
+		query += " AND [System.WorkItemType] IN (" + wiqlList(c.config.Query.WorkItemTypes) + ")"
 	}
 
 	if len(c.config.Query.States) > 0 {
-		query += " AND [System.State] IN ("
-		for i, state := range c.config.Query.States {
-			if i > 0 {
-				query += ", "
-			}
-			query += fmt.Sprintf("'%s'", state)
-		}
-		query += ")"
+		query += " AND [System.State] IN (" + wiqlList(c.config.Query.States) + ")"
 	}
 
-	if len(c.config.Query.AreaPaths) > 0 {
-		query += " AND [System.AreaPath] UNDER ("
-		for i, areaPath := range c.config.Query.AreaPaths {
-			if i > 0 {
-				query += " OR [System.AreaPath] UNDER "
-			}
-			query += fmt.Sprintf("'%s'", areaPath)
+	if len(areaPaths) > 0 {
+		clauses := make([]string, len(areaPaths))
+		for i, areaPath := range areaPaths {
+			clauses[i] = "[System.AreaPath] UNDER " + wiqlLiteral(areaPath)
 		}
-		query += ")"
+		query += " AND (" + strings.Join(clauses, " OR ") + ")"
+	}
+
+	if len(c.config.Query.ExcludeTypes) > 0 {
+		query += " AND [System.WorkItemType] NOT IN (" + wiqlList(c.config.Query.ExcludeTypes) + ")"
+	}
+
+	if c.config.Query.MinID > 0 {
+		query += fmt.Sprintf(" AND [System.Id] >= %d", c.config.Query.MinID)
+	}
+
+	if c.config.Query.MaxID > 0 {
+		query += fmt.Sprintf(" AND [System.Id] <= %d", c.config.Query.MaxID)
 	}
 
 	return query
 }
 
-func (c *Client) getWorkItemDetails(ctx context.Context, workItemIds []int) ([]*models.WorkItem, error) {
-	var workItems []*models.WorkItem
+// wiqlLiteral quotes value as a WIQL string literal. Single quotes are escaped by
+// doubling them; everything else, brackets included, is literal inside the quotes.
+func wiqlLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// wiqlList quotes values as a comma separated list of WIQL string literals
+func wiqlList(values []string) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = wiqlLiteral(value)
+	}
+
+	return strings.Join(literals, ", ")
+}
+
+func (c *Client) filterIDRange(workItemIds []int) []int {
+	if c.config.Query.MinID == 0 && c.config.Query.MaxID == 0 {
+		return workItemIds
+	}
+
+	filtered := make([]int, 0, len(workItemIds))
+	for _, id := range workItemIds {
+		if c.config.Query.InIDRange(id) {
+			filtered = append(filtered, id)
+		}
+	}
+
+	if excluded := len(workItemIds) - len(filtered); excluded > 0 {
+		c.logger.Info("Excluded work items outside the ID range",
+			"excluded", excluded,
+			"min_id", c.config.Query.MinID,
+			"max_id", c.config.Query.MaxID)
+	}
 
+	return filtered
+}
+
+// getWorkItemDetails retrieves work item batches concurrently and passes each to handle
+// in query order. A batch that completes early waits in its slot until every earlier
+// batch is handled; no batch is dispatched while concurrency batches are waiting or in
+// flight, which bounds memory on large queries.
+func (c *Client) getWorkItemDetails(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
 	// Get work items in batches to avoid API limits
 	batchSize := 100 // ADO API limit
+	var batches [][]int
 	for i := 0; i < len(workItemIds); i += batchSize {
 		end := i + batchSize
 		if end > len(workItemIds) {
 			end = len(workItemIds)
 		}
+		batches = append(batches, workItemIds[i:end])
+	}
+
+	concurrency := c.config.MaxConcurrentRequests
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(batches) {
+		concurrency = len(batches)
+	}
 
-		batch := workItemIds[i:end]
-		c.logger.Debug("Retrieving work item batch", "start", i+1, "end", end)
+	c.logger.Debug("Retrieving work item details", "batches", len(batches), "concurrency", concurrency)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]*models.WorkItem, len(batches))
+	fetched := make([]bool, len(batches))
+	next := 0 // Next batch to hand over, guarded by resultsMu
+	var resultsMu sync.Mutex
+	slots := make(chan struct{}, concurrency)
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
 
-		batchItems, err := c.getWorkItemBatch(ctx, batch)
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve work item batch: %w", err)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				// A failed batch cancels the fetch, the rest of the queue is dropped unsent
+				if ctx.Err() != nil {
+					continue
+				}
+
+				batch := batches[index]
+				c.logger.Debug("Retrieving work item batch", "start", index*batchSize+1, "end", index*batchSize+len(batch))
+
+				batchItems, err := c.getWorkItemBatchWithRetry(ctx, batch)
+				if err != nil {
+					fail(fmt.Errorf("failed to retrieve work item batch: %w", err))
+					continue
+				}
+
+				resultsMu.Lock()
+				results[index] = batchItems
+				fetched[index] = true
+				for next < len(batches) && fetched[next] && ctx.Err() == nil {
+					if err := handle(results[next]); err != nil {
+						fail(err)
+						break
+					}
+					results[next] = nil
+					next++
+					<-slots
+				}
+				resultsMu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for index := range batches {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break dispatch
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		workItems = append(workItems, batchItems...)
+	if firstErr != nil {
+		return firstErr
 	}
 
-	return workItems, nil
+	return ctx.Err()
+}
+
+// getWorkItemBatchWithRetry retries a batch request when ADO throttles the caller,
+// backing off exponentially so concurrent workers don't hammer the service.
+func (c *Client) getWorkItemBatchWithRetry(ctx context.Context, ids []int) ([]*models.WorkItem, error) {
+	return retryThrottled(ctx, c.logger, c.observeWait, func() ([]*models.WorkItem, error) {
+		return c.getWorkItemBatch(ctx, ids)
+	})
+}
+
+// retryThrottled calls request until it succeeds, fails with something other than
+// throttling or runs out of attempts. observe, when set, is called with every backoff.
+func retryThrottled[T any](ctx context.Context, logger *slog.Logger, observe func(time.Duration), request func() (T, error)) (T, error) {
+	delay := throttleBaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := request()
+		if err == nil || !isThrottled(err) || attempt >= maxThrottleRetries {
+			return result, err
+		}
+
+		logger.Warn("Azure DevOps throttled the request, backing off", "attempt", attempt, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		if observe != nil {
+			observe(delay)
+		}
+		delay *= 2
+	}
+}
+
+// isThrottled reports whether err is an ADO response asking the caller to slow down
+func isThrottled(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// statusCode returns the HTTP status of an ADO error response, or 0 when err is not one
+func statusCode(err error) int {
+	var wrappedErr azuredevops.WrappedError
+	if errors.As(err, &wrappedErr) && wrappedErr.StatusCode != nil {
+		return *wrappedErr.StatusCode
+	}
+
+	var wrappedErrPtr *azuredevops.WrappedError
+	if errors.As(err, &wrappedErrPtr) && wrappedErrPtr.StatusCode != nil {
+		return *wrappedErrPtr.StatusCode
+	}
+
+	return 0
 }
 
 func (c *Client) getWorkItemBatch(ctx context.Context, ids []int) ([]*models.WorkItem, error) {
@@ -209,6 +510,9 @@ func (c *Client) getWorkItemBatch(ctx context.Context, ids []int) ([]*models.Wor
 	if response != nil {
 		for _, adoWorkItem := range *response {
 			workItem := c.convertToWorkItem(adoWorkItem)
+			if c.retainedFields != nil {
+				workItem.RetainFields(c.retainedFields)
+			}
 			workItems = append(workItems, workItem)
 		}
 	}
@@ -244,39 +548,60 @@ func (c *Client) convertToWorkItem(adoWorkItem workitemtracking.WorkItem) *model
 
 	if adoWorkItem.Relations != nil {
 		for _, relation := range *adoWorkItem.Relations {
-			workItem.Relations = append(workItem.Relations, models.WorkItemRelation{
+			converted := models.WorkItemRelation{
 				Rel: getStringPtr(relation.Rel),
 				URL: getStringPtr(relation.Url),
-			})
+			}
+			// Attributes carry the link comment, attachment name and resource size
+			if relation.Attributes != nil && len(*relation.Attributes) > 0 {
+				converted.Attributes = make(map[string]interface{}, len(*relation.Attributes))
+				for key, value := range *relation.Attributes {
+					converted.Attributes[key] = value
+				}
+			}
+			workItem.Relations = append(workItem.Relations, converted)
 		}
 	}
 
 	return workItem
 }
 
+// GetWorkItemComments returns every comment on the work item, oldest first
 func (c *Client) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
-	getCommentsArgs := workitemtracking.GetCommentsArgs{
-		Project:    &c.config.Project,
-		WorkItemId: &workItemID,
-	}
+	order := workitemtracking.CommentSortOrderValues.Asc
+	var comments []models.WorkItemComment
+	var continuationToken *string
+
+	for {
+		getCommentsArgs := workitemtracking.GetCommentsArgs{
+			Project:           &c.config.Project,
+			WorkItemId:        &workItemID,
+			Order:             &order,
+			ContinuationToken: continuationToken,
+		}
 
-	response, err := c.witClient.GetComments(ctx, getCommentsArgs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get comments for work item %d: %w", workItemID, err)
-	}
+		response, err := c.witClient.GetComments(ctx, getCommentsArgs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get comments for work item %d: %w", workItemID, err)
+		}
 
-	var comments []models.WorkItemComment
-	if response.Comments != nil {
-		for _, comment := range *response.Comments {
-			comments = append(comments, models.WorkItemComment{
-				ID:   getIntPtr(comment.Id),
-				Text: getStringPtr(comment.Text),
-				CreatedBy: models.User{
-					DisplayName: *comment.CreatedBy.DisplayName,
-				},
-				CreatedDate: comment.CreatedDate.Time,
-			})
+		if response.Comments != nil {
+			for _, comment := range *response.Comments {
+				comments = append(comments, models.WorkItemComment{
+					ID:   getIntPtr(comment.Id),
+					Text: getStringPtr(comment.Text),
+					CreatedBy: models.User{
+						DisplayName: *comment.CreatedBy.DisplayName,
+					},
+					CreatedDate: comment.CreatedDate.Time,
+				})
+			}
+		}
+
+		if response.ContinuationToken == nil || *response.ContinuationToken == "" {
+			break
 		}
+		continuationToken = response.ContinuationToken
 	}
 
 	return comments, nil
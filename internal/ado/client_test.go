@@ -0,0 +1,709 @@
+package ado
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/webapi"
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+func newTestClient(cfg *config.AzureDevOpsConfig) *Client {
+	return &Client{
+		config:     cfg,
+		logger:     slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})),
+		identities: map[string]*models.User{},
+	}
+}
+
+func TestWIQLLiteral(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"MyProject", "'MyProject'"},
+		{"Team's Project", "'Team''s Project'"},
+		{"Project [Legacy]", "'Project [Legacy]'"},
+		{"''", "''''''"},
+		{"", "''"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, wiqlLiteral(tt.value))
+		})
+	}
+}
+
+func TestBuildDefaultQuery(t *testing.T) {
+	t.Run("project name with quotes and brackets", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "O'Brien [Legacy] (2024)"})
+
+		assert.Equal(t,
+			"SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = 'O''Brien [Legacy] (2024)'",
+			client.buildDefaultQuery(nil))
+	})
+
+	t.Run("filters escape every literal", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{
+			Project: "Team's Project",
+			Query: config.WorkItemQuery{
+				WorkItemTypes: []string{"Bug", "Customer's Request"},
+				States:        []string{"Won't Fix"},
+				ExcludeTypes:  []string{"Test Case"},
+				MinID:         10,
+				MaxID:         20,
+			},
+		})
+
+		assert.Equal(t,
+			"SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = 'Team''s Project'"+
+				" AND [System.WorkItemType] IN ('Bug', 'Customer''s Request')"+
+				" AND [System.State] IN ('Won''t Fix')"+
+				" AND ([System.AreaPath] UNDER 'Team''s Project\\Area')"+
+				" AND [System.WorkItemType] NOT IN ('Test Case')"+
+				" AND [System.Id] >= 10 AND [System.Id] <= 20",
+			client.buildDefaultQuery([]string{"Team's Project\\Area"}))
+	})
+
+	t.Run("area paths are grouped", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Project"})
+
+		assert.Equal(t,
+			"SELECT [System.Id] FROM WorkItems WHERE [System.TeamProject] = 'Project'"+
+				" AND ([System.AreaPath] UNDER 'Project\\Web' OR [System.AreaPath] UNDER 'Project\\API')",
+			client.buildDefaultQuery([]string{"Project\\Web", "Project\\API"}))
+	})
+}
+
+func TestNormalizeAreaPath(t *testing.T) {
+	known := []string{
+		"My Project",
+		"My Project\\Web",
+		"My Project\\Web\\Frontend",
+		"My Project\\API",
+	}
+
+	tests := []struct {
+		name       string
+		configured string
+		expected   string
+		found      bool
+	}{
+		{"wiql form", "My Project\\Web", "My Project\\Web", true},
+		{"leading backslash", "\\My Project\\Web\\Frontend", "My Project\\Web\\Frontend", true},
+		{"classification form", "\\My Project\\Area\\API", "My Project\\API", true},
+		{"without project", "Web\\Frontend", "My Project\\Web\\Frontend", true},
+		{"forward slashes and casing", "my project/web/", "My Project\\Web", true},
+		{"project root", "\\My Project", "My Project", true},
+		{"unknown path", "\\My Project\\Mobile", "My Project\\Mobile", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, found := normalizeAreaPath(tt.configured, "My Project", known)
+			assert.Equal(t, tt.expected, normalized)
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
+
+func TestClosestAreaPaths(t *testing.T) {
+	known := []string{
+		"Project",
+		"Project\\Frontend",
+		"Project\\Frontend\\Checkout",
+		"Project\\Backend",
+	}
+
+	t.Run("typo suggests the close match", func(t *testing.T) {
+		assert.Equal(t, []string{"Project\\Frontend"}, closestAreaPaths("Project\\Fronted", known, 3))
+	})
+
+	t.Run("case differences are ignored", func(t *testing.T) {
+		assert.Equal(t, []string{"Project\\Backend"}, closestAreaPaths("project\\backend\\", known, 3))
+	})
+
+	t.Run("unrelated path has no suggestions", func(t *testing.T) {
+		assert.Empty(t, closestAreaPaths("Project\\Mobile", known, 3))
+	})
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("area", "area"))
+	assert.Equal(t, 1, levenshtein("area", "arena"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "team"))
+}
+
+func TestConvertIteration(t *testing.T) {
+	name := func(value string) *string { return &value }
+	id := func(value int) *int { return &value }
+
+	children := []workitemtracking.WorkItemClassificationNode{
+		{
+			Id:   id(2),
+			Name: name("Sprint 1"),
+			Attributes: &map[string]interface{}{
+				"startDate":  "2024-01-01T00:00:00Z",
+				"finishDate": "2024-01-14T00:00:00Z",
+			},
+		},
+		{Id: id(3), Name: name("Backlog")},
+	}
+	root := workitemtracking.WorkItemClassificationNode{Id: id(1), Name: name("Project"), Children: &children}
+
+	iteration := convertIteration(root, "")
+
+	assert.Equal(t, "Project", iteration.Path)
+	require.Len(t, iteration.Children, 2)
+
+	sprint := iteration.Children[0]
+	assert.Equal(t, 2, sprint.ID)
+	assert.Equal(t, "Project\\Sprint 1", sprint.Path)
+	require.NotNil(t, sprint.StartDate)
+	require.NotNil(t, sprint.FinishDate)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *sprint.StartDate)
+	assert.Equal(t, time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), *sprint.FinishDate)
+
+	backlog := iteration.Children[1]
+	assert.Equal(t, "Project\\Backlog", backlog.Path)
+	assert.Nil(t, backlog.StartDate)
+	assert.Nil(t, backlog.FinishDate)
+}
+
+type fakeIdentityClient struct {
+	identity.Client
+	identities map[string]identity.Identity
+	calls      int
+}
+
+func (f *fakeIdentityClient) ReadIdentities(_ context.Context, args identity.ReadIdentitiesArgs) (*[]identity.Identity, error) {
+	f.calls++
+
+	var key string
+	switch {
+	case args.IdentityIds != nil:
+		key = *args.IdentityIds
+	case args.SubjectDescriptors != nil:
+		key = *args.SubjectDescriptors
+	case args.Descriptors != nil:
+		key = *args.Descriptors
+	}
+
+	found, ok := f.identities[key]
+	if !ok {
+		return &[]identity.Identity{{}}, nil
+	}
+	return &[]identity.Identity{found}, nil
+}
+
+func TestResolveIdentity(t *testing.T) {
+	name := func(value string) *string { return &value }
+
+	fake := &fakeIdentityClient{identities: map[string]identity.Identity{
+		"aad.ZmFrZS1zdWJqZWN0": {
+			Descriptor:          name("Microsoft.IdentityModel.Claims.ClaimsIdentity;jane@example.com"),
+			ProviderDisplayName: name("Jane Doe"),
+			Properties: map[string]interface{}{
+				"Mail":    map[string]interface{}{"$type": "System.String", "$value": "jane@example.com"},
+				"Account": map[string]interface{}{"$type": "System.String", "$value": "jane@corp.example.com"},
+			},
+		},
+		"Microsoft.TeamFoundation.ServiceIdentity;build": {
+			Descriptor:          name("Microsoft.TeamFoundation.ServiceIdentity;build"),
+			ProviderDisplayName: name("Build Service"),
+			CustomDisplayName:   name("Project Build Service"),
+		},
+	}}
+	client := newTestClient(&config.AzureDevOpsConfig{})
+	client.identityClient = fake
+
+	t.Run("subject descriptor resolves to name and email", func(t *testing.T) {
+		user, err := client.ResolveIdentity(context.Background(), "aad.ZmFrZS1zdWJqZWN0")
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Doe", user.DisplayName)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "jane@corp.example.com", user.UniqueName)
+	})
+
+	t.Run("custom display name wins", func(t *testing.T) {
+		user, err := client.ResolveIdentity(context.Background(), "Microsoft.TeamFoundation.ServiceIdentity;build")
+		require.NoError(t, err)
+		assert.Equal(t, "Project Build Service", user.DisplayName)
+		assert.Empty(t, user.Email)
+	})
+
+	t.Run("results and misses are cached", func(t *testing.T) {
+		calls := fake.calls
+
+		_, err := client.ResolveIdentity(context.Background(), "AAD.ZmFrZS1zdWJqZWN0")
+		require.NoError(t, err)
+
+		_, err = client.ResolveIdentity(context.Background(), "0d6a2f4e-5c1b-4f3a-9e8d-7c6b5a4f3e2d")
+		assert.ErrorIs(t, err, ErrIdentityNotFound)
+		_, err = client.ResolveIdentity(context.Background(), "0d6a2f4e-5c1b-4f3a-9e8d-7c6b5a4f3e2d")
+		assert.ErrorIs(t, err, ErrIdentityNotFound)
+
+		assert.Equal(t, calls+1, fake.calls)
+	})
+}
+
+func TestConvertToWorkItemRelations(t *testing.T) {
+	str := func(value string) *string { return &value }
+	id := 42
+
+	relations := []workitemtracking.WorkItemRelation{
+		{
+			Rel: str("AttachedFile"),
+			Url: str("https://dev.azure.com/org/_apis/wit/attachments/abc"),
+			Attributes: &map[string]interface{}{
+				"name":         "screenshot.png",
+				"resourceSize": float64(2048),
+				"comment":      "repro",
+			},
+		},
+		{
+			Rel: str("System.LinkTypes.Hierarchy-Reverse"),
+			Url: str("https://dev.azure.com/org/_apis/wit/workItems/7"),
+		},
+	}
+
+	client := newTestClient(&config.AzureDevOpsConfig{})
+	workItem := client.convertToWorkItem(workitemtracking.WorkItem{Id: &id, Relations: &relations})
+
+	require.Len(t, workItem.Relations, 2)
+	attachment := workItem.Relations[0]
+	assert.Equal(t, "AttachedFile", attachment.Rel)
+	assert.Equal(t, "screenshot.png", attachment.GetAttributeString("name"))
+	assert.Equal(t, "repro", attachment.GetAttributeString("comment"))
+	assert.Equal(t, int64(2048), attachment.GetAttributeInt("resourceSize"))
+
+	assert.Nil(t, workItem.Relations[1].Attributes)
+}
+
+func TestTallyTypeStates(t *testing.T) {
+	workItem := func(workItemType, state string) *models.WorkItem {
+		return &models.WorkItem{Fields: map[string]interface{}{"System.WorkItemType": workItemType, "System.State": state}}
+	}
+
+	tally := tallyTypeStates([]*models.WorkItem{
+		workItem("Bug", "Active"),
+		workItem("Test Case", "Design"),
+		workItem("Test Case", "Design"),
+		workItem("Bug", "Closed"),
+		workItem("Test Case", "Design"),
+		workItem("Bug", "Active"),
+	})
+
+	assert.Equal(t, []TypeStateCount{
+		{Type: "Test Case", State: "Design", Count: 3},
+		{Type: "Bug", State: "Active", Count: 2},
+		{Type: "Bug", State: "Closed", Count: 1},
+	}, tally)
+}
+
+// fakeWorkItemClient answers WIQL queries with queryIDs and detail requests from items.
+// When set, respond is called before each detail request and can fail or delay it.
+type fakeWorkItemClient struct {
+	workitemtracking.Client
+	mu       sync.Mutex
+	queryIDs []int
+	items    map[int]map[string]interface{}
+	queries  int
+	requests []workitemtracking.GetWorkItemsArgs
+	respond  func(ctx context.Context, ids []int) error
+}
+
+func (f *fakeWorkItemClient) QueryByWiql(_ context.Context, _ workitemtracking.QueryByWiqlArgs) (*workitemtracking.WorkItemQueryResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+
+	references := make([]workitemtracking.WorkItemReference, len(f.queryIDs))
+	for i := range f.queryIDs {
+		references[i] = workitemtracking.WorkItemReference{Id: &f.queryIDs[i]}
+	}
+	return &workitemtracking.WorkItemQueryResult{WorkItems: &references}, nil
+}
+
+func (f *fakeWorkItemClient) GetWorkItems(ctx context.Context, args workitemtracking.GetWorkItemsArgs) (*[]workitemtracking.WorkItem, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, args)
+	f.mu.Unlock()
+
+	if f.respond != nil {
+		if err := f.respond(ctx, *args.Ids); err != nil {
+			return nil, err
+		}
+	}
+
+	var workItems []workitemtracking.WorkItem
+	for _, id := range *args.Ids {
+		fields := map[string]interface{}{}
+		for name, value := range f.items[id] {
+			if args.Fields == nil || slices.Contains(*args.Fields, name) {
+				fields[name] = value
+			}
+		}
+		workItems = append(workItems, workitemtracking.WorkItem{Id: &id, Fields: &fields})
+	}
+	return &workItems, nil
+}
+
+// newFakeWorkItemClient serves work items 1 to len(types) with the given types, all Active
+func newFakeWorkItemClient(types ...string) *fakeWorkItemClient {
+	fake := &fakeWorkItemClient{items: map[int]map[string]interface{}{}}
+	for i, workItemType := range types {
+		fake.queryIDs = append(fake.queryIDs, i+1)
+		fake.items[i+1] = map[string]interface{}{
+			"System.WorkItemType": workItemType,
+			"System.State":        "Active",
+			"System.Title":        fmt.Sprintf("%s %d", workItemType, i+1),
+		}
+	}
+	return fake
+}
+
+func TestFilterExcludedTypes(t *testing.T) {
+	types := []string{"Bug", "Test Case", "shared steps", "User Story", "TEST CASE"}
+
+	tests := []struct {
+		name  string
+		query config.WorkItemQuery
+	}{
+		{
+			name:  "user WIQL",
+			query: config.WorkItemQuery{WIQL: "SELECT [System.Id] FROM WorkItems", ExcludeTypes: []string{"test case", "Shared Steps"}},
+		},
+		{
+			name:  "ID list",
+			query: config.WorkItemQuery{IDs: []int{1, 2, 3, 4, 5}, ExcludeTypes: []string{"test case", "Shared Steps"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam", Query: tt.query})
+			client.witClient = newFakeWorkItemClient(types...)
+
+			workItems, err := client.GetWorkItems(context.Background())
+			require.NoError(t, err)
+
+			ids := make([]int, len(workItems))
+			for i, workItem := range workItems {
+				ids[i] = workItem.ID
+			}
+			assert.Equal(t, []int{1, 4}, ids)
+		})
+	}
+}
+
+func TestSummarizeQuery(t *testing.T) {
+	t.Run("counts come from a single query", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{
+			Project: "Fabrikam",
+			Query:   config.WorkItemQuery{WIQL: "SELECT [System.Id] FROM WorkItems", ExcludeTypes: []string{"Test Case"}},
+		})
+		fake := newFakeWorkItemClient("Bug", "Test Case", "Bug", "User Story", "test case")
+		client.witClient = fake
+
+		summary, err := client.SummarizeQuery(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, fake.queries)
+		assert.Equal(t, 3, summary.Matching)
+		assert.Equal(t, 2, summary.Excluded)
+		assert.Equal(t, []TypeStateCount{
+			{Type: "Bug", State: "Active", Count: 2},
+			{Type: "User Story", State: "Active", Count: 1},
+		}, summary.TypeStates)
+
+		total := 0
+		for _, count := range summary.TypeStates {
+			total += count.Count
+		}
+		assert.Equal(t, summary.Matching, total)
+	})
+
+	t.Run("only type and state are fetched in batches of 100", func(t *testing.T) {
+		types := make([]string, 250)
+		for i := range types {
+			types[i] = "Bug"
+		}
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam"})
+		fake := newFakeWorkItemClient(types...)
+		client.witClient = fake
+
+		summary, err := client.SummarizeQuery(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 250, summary.Matching)
+		assert.Zero(t, summary.Excluded)
+		require.Len(t, fake.requests, 3)
+		assert.Len(t, *fake.requests[0].Ids, 100)
+		assert.Len(t, *fake.requests[2].Ids, 50)
+		for _, request := range fake.requests {
+			assert.Equal(t, []string{"System.WorkItemType", "System.State"}, *request.Fields)
+		}
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam", Query: config.WorkItemQuery{IDs: []int{}}})
+		fake := newFakeWorkItemClient()
+		client.witClient = fake
+
+		summary, err := client.SummarizeQuery(context.Background())
+		require.NoError(t, err)
+
+		assert.Zero(t, summary.Matching)
+		assert.Empty(t, summary.TypeStates)
+		assert.Empty(t, fake.requests)
+	})
+}
+
+// setThrottleBaseDelay shortens the throttling backoff until the test finishes
+func setThrottleBaseDelay(t *testing.T, delay time.Duration) {
+	previous := throttleBaseDelay
+	throttleBaseDelay = delay
+	t.Cleanup(func() { throttleBaseDelay = previous })
+}
+
+func adoError(code int) error {
+	return azuredevops.WrappedError{StatusCode: &code}
+}
+
+func TestGetWorkItemDetails(t *testing.T) {
+	types := make([]string, 1000)
+	for i := range types {
+		types[i] = "Bug"
+	}
+	ids := make([]int, len(types))
+	for i := range ids {
+		ids[i] = len(ids) - i
+	}
+	collect := func(workItems *[]int) func([]*models.WorkItem) error {
+		return func(batch []*models.WorkItem) error {
+			for _, workItem := range batch {
+				*workItems = append(*workItems, workItem.ID)
+			}
+			return nil
+		}
+	}
+
+	t.Run("concurrent batches are handled in query order", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam", MaxConcurrentRequests: 3})
+		fake := newFakeWorkItemClient(types...)
+		var handled atomic.Int32
+		// Earlier batches answer last
+		fake.respond = func(ctx context.Context, batch []int) error {
+			index := (len(ids) - batch[0]) / 100
+			assert.Less(t, index-int(handled.Load()), 3, "batch %d dispatched while 3 are held", index)
+			time.Sleep(time.Duration(batch[0]) * 20 * time.Microsecond)
+			return nil
+		}
+		client.witClient = fake
+
+		var fetched []int
+		err := client.getWorkItemDetails(context.Background(), ids, func(batch []*models.WorkItem) error {
+			handled.Add(1)
+			return collect(&fetched)(batch)
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, ids, fetched)
+		assert.Len(t, fake.requests, 10)
+	})
+
+	t.Run("the first error cancels the other batches", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam", MaxConcurrentRequests: 2})
+		fake := newFakeWorkItemClient(types...)
+		var cancelled atomic.Int32
+		inFlight := make(chan struct{})
+		fake.respond = func(ctx context.Context, batch []int) error {
+			// The first batch fails once the second one is in flight
+			if batch[0] == ids[0] {
+				<-inFlight
+				return adoError(http.StatusInternalServerError)
+			}
+			close(inFlight)
+
+			select {
+			case <-ctx.Done():
+				cancelled.Add(1)
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}
+		client.witClient = fake
+
+		start := time.Now()
+		var fetched []int
+		err := client.getWorkItemDetails(context.Background(), ids, collect(&fetched))
+		require.ErrorContains(t, err, "failed to retrieve work item batch")
+		assert.Equal(t, http.StatusInternalServerError, statusCode(err))
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		// The batch in flight with the failure is cancelled and no further batch is sent
+		assert.Equal(t, int32(1), cancelled.Load())
+		assert.Len(t, fake.requests, 2)
+		assert.Empty(t, fetched)
+	})
+
+	t.Run("a handler error stops the fetch", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam", MaxConcurrentRequests: 2})
+		fake := newFakeWorkItemClient(types...)
+		client.witClient = fake
+
+		handled := 0
+		err := client.getWorkItemDetails(context.Background(), ids, func(batch []*models.WorkItem) error {
+			handled++
+			return errors.New("disk full")
+		})
+		require.EqualError(t, err, "disk full")
+		assert.Equal(t, 1, handled)
+		assert.Less(t, len(fake.requests), 10)
+	})
+}
+
+func TestRetryThrottled(t *testing.T) {
+	setThrottleBaseDelay(t, 10*time.Millisecond)
+	logger := slog.New(slog.DiscardHandler)
+
+	t.Run("backs off exponentially while throttled", func(t *testing.T) {
+		client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam"})
+		fake := newFakeWorkItemClient("Bug")
+		throttles := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+		fake.respond = func(ctx context.Context, batch []int) error {
+			if len(throttles) == 0 {
+				return nil
+			}
+			code := throttles[0]
+			throttles = throttles[1:]
+			return adoError(code)
+		}
+		client.witClient = fake
+		var waits []time.Duration
+		client.SetRateLimitObserver(func(wait time.Duration) { waits = append(waits, wait) })
+
+		start := time.Now()
+		workItems, err := client.getWorkItemBatchWithRetry(context.Background(), []int{1})
+		require.NoError(t, err)
+		require.Len(t, workItems, 1)
+		assert.Len(t, fake.requests, 3)
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+		assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, waits, "every backoff is reported")
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		attempts := 0
+		_, err := retryThrottled(context.Background(), logger, nil, func() (int, error) {
+			attempts++
+			return 0, adoError(http.StatusTooManyRequests)
+		})
+		require.Error(t, err)
+		assert.True(t, isThrottled(err))
+		assert.Equal(t, maxThrottleRetries, attempts)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		attempts := 0
+		_, err := retryThrottled(context.Background(), logger, nil, func() (int, error) {
+			attempts++
+			return 0, adoError(http.StatusUnauthorized)
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("stops waiting when the context is cancelled", func(t *testing.T) {
+		setThrottleBaseDelay(t, time.Hour)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := retryThrottled(ctx, logger, nil, func() (int, error) {
+			return 0, adoError(http.StatusTooManyRequests)
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// fakeCommentClient serves comments in pages of pageSize, oldest first
+type fakeCommentClient struct {
+	workitemtracking.Client
+	comments []string
+	pageSize int
+	requests []workitemtracking.GetCommentsArgs
+}
+
+func (f *fakeCommentClient) GetComments(_ context.Context, args workitemtracking.GetCommentsArgs) (*workitemtracking.CommentList, error) {
+	f.requests = append(f.requests, args)
+
+	start := 0
+	if args.ContinuationToken != nil {
+		start, _ = strconv.Atoi(*args.ContinuationToken)
+	}
+	end := min(start+f.pageSize, len(f.comments))
+
+	author := "Ana Lima"
+	var page []workitemtracking.Comment
+	for i := start; i < end; i++ {
+		id := i + 1
+		page = append(page, workitemtracking.Comment{
+			Id:          &id,
+			Text:        &f.comments[i],
+			CreatedBy:   &webapi.IdentityRef{DisplayName: &author},
+			CreatedDate: &azuredevops.Time{Time: time.Date(2024, 3, i+1, 9, 0, 0, 0, time.UTC)},
+		})
+	}
+
+	list := &workitemtracking.CommentList{Comments: &page}
+	if end < len(f.comments) {
+		token := strconv.Itoa(end)
+		list.ContinuationToken = &token
+	}
+	return list, nil
+}
+
+func TestGetWorkItemComments(t *testing.T) {
+	client := newTestClient(&config.AzureDevOpsConfig{Project: "Fabrikam"})
+	fake := &fakeCommentClient{comments: []string{"first", "second", "third"}, pageSize: 2}
+	client.witClient = fake
+
+	comments, err := client.GetWorkItemComments(context.Background(), 42)
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 2)
+	for _, request := range fake.requests {
+		assert.Equal(t, 42, *request.WorkItemId)
+		assert.Equal(t, workitemtracking.CommentSortOrderValues.Asc, *request.Order)
+	}
+	assert.Nil(t, fake.requests[0].ContinuationToken)
+	assert.Equal(t, "2", *fake.requests[1].ContinuationToken)
+
+	require.Len(t, comments, 3)
+	for i, comment := range comments {
+		assert.Equal(t, i+1, comment.ID)
+		assert.Equal(t, fake.comments[i], comment.Text)
+		assert.Equal(t, "Ana Lima", comment.CreatedBy.DisplayName)
+	}
+	assert.True(t, comments[0].CreatedDate.Before(comments[2].CreatedDate))
+}
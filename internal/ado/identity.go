@@ -0,0 +1,126 @@
+package ado
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/identity"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// ErrIdentityNotFound is returned when ADO knows no identity for a descriptor
+var ErrIdentityNotFound = errors.New("identity not found")
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Graph subject descriptors are prefixed with their origin, e.g. "aad.", "msa." or "vssgp."
+var subjectDescriptorPattern = regexp.MustCompile(`^[a-z]{2,6}\.[A-Za-z0-9_=-]+$`)
+
+// ResolveIdentity turns an identity reference found in work item HTML into a user.
+// descriptor may be an identity GUID (as in data-vss-mention), a graph subject descriptor
+// or a legacy identity descriptor. Results, including misses, are cached for the run.
+func (c *Client) ResolveIdentity(ctx context.Context, descriptor string) (*models.User, error) {
+	descriptor = strings.TrimSpace(descriptor)
+	key := strings.ToLower(descriptor)
+
+	c.identityMu.Lock()
+	user, cached := c.identities[key]
+	c.identityMu.Unlock()
+	if cached {
+		if user == nil {
+			return nil, fmt.Errorf("%w: %s", ErrIdentityNotFound, descriptor)
+		}
+		return user, nil
+	}
+
+	args := identity.ReadIdentitiesArgs{}
+	switch {
+	case guidPattern.MatchString(descriptor):
+		args.IdentityIds = &descriptor
+	case subjectDescriptorPattern.MatchString(descriptor):
+		args.SubjectDescriptors = &descriptor
+	default:
+		args.Descriptors = &descriptor
+	}
+
+	identities, err := c.identityClient.ReadIdentities(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve identity %s: %w", descriptor, err)
+	}
+
+	if identities != nil {
+		for _, found := range *identities {
+			// Unknown IDs come back as null entries
+			if found.Id == nil && found.Descriptor == nil {
+				continue
+			}
+			user = convertIdentity(found)
+			break
+		}
+	}
+
+	c.identityMu.Lock()
+	c.identities[key] = user
+	c.identityMu.Unlock()
+
+	if user == nil {
+		return nil, fmt.Errorf("%w: %s", ErrIdentityNotFound, descriptor)
+	}
+
+	return user, nil
+}
+
+func convertIdentity(found identity.Identity) *models.User {
+	user := &models.User{
+		DisplayName: getStringPtr(found.ProviderDisplayName),
+	}
+	if found.CustomDisplayName != nil && *found.CustomDisplayName != "" {
+		user.DisplayName = *found.CustomDisplayName
+	}
+	if found.Id != nil {
+		user.ID = found.Id.String()
+	}
+
+	properties := identityProperties(found.Properties)
+	user.Email = properties["Mail"]
+	user.UniqueName = properties["Account"]
+	if user.Email == "" && strings.Contains(user.UniqueName, "@") {
+		user.Email = user.UniqueName
+	}
+
+	return user
+}
+
+// identityProperties flattens the identity property bag, where every value is
+// wrapped as {"$type": ..., "$value": ...}, to its string values
+func identityProperties(properties interface{}) map[string]string {
+	values := map[string]string{}
+	if properties == nil {
+		return values
+	}
+
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return values
+	}
+
+	var bag map[string]struct {
+		Value interface{} `json:"$value"`
+	}
+	if err := json.Unmarshal(data, &bag); err != nil {
+		return values
+	}
+
+	for key, property := range bag {
+		if value, ok := property.Value.(string); ok {
+			values[key] = value
+		}
+	}
+
+	return values
+}
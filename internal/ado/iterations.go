@@ -0,0 +1,66 @@
+package ado
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// GetIterations returns the iteration tree of the project. The root is the project
+// node; sprints and releases are its descendants with their start and finish dates.
+func (c *Client) GetIterations(ctx context.Context) (*models.Iteration, error) {
+	root, err := c.getClassificationTree(ctx, workitemtracking.TreeStructureGroupValues.Iterations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterations: %w", err)
+	}
+
+	iteration := convertIteration(*root, "")
+	return &iteration, nil
+}
+
+// convertIteration converts a classification node and its children. parent is the
+// iteration path of the parent node, empty for the root.
+func convertIteration(node workitemtracking.WorkItemClassificationNode, parent string) models.Iteration {
+	iteration := models.Iteration{
+		ID:   getIntPtr(node.Id),
+		Name: getStringPtr(node.Name),
+	}
+
+	iteration.Path = iteration.Name
+	if parent != "" {
+		iteration.Path = parent + `\` + iteration.Name
+	}
+
+	if node.Attributes != nil {
+		iteration.StartDate = attributeTime(*node.Attributes, "startDate")
+		iteration.FinishDate = attributeTime(*node.Attributes, "finishDate")
+	}
+
+	if node.Children != nil {
+		for _, child := range *node.Children {
+			iteration.Children = append(iteration.Children, convertIteration(child, iteration.Path))
+		}
+	}
+
+	return iteration
+}
+
+// attributeTime reads a date attribute, returning nil when it is missing or not a valid date
+func attributeTime(attributes map[string]interface{}, key string) *time.Time {
+	switch value := attributes[key].(type) {
+	case time.Time:
+		return &value
+	case string:
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil
+		}
+		return &parsed
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package ado
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/microsoft/azure-devops-go-api/azuredevops/v7/workitemtracking"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// statsBatchSize is the most work items ADO returns per details request
+const statsBatchSize = 100
+
+// TypeStateCount is the number of queried work items of one type in one state
+type TypeStateCount struct {
+	Type  string
+	State string
+	Count int
+}
+
+// QuerySummary describes the work items the configured query matches
+type QuerySummary struct {
+	Matching   int              // Work items a migration would fetch
+	Excluded   int              // Matches left out because their type is in exclude_types
+	TypeStates []TypeStateCount // Matching work items by type and state, largest groups first
+}
+
+// SummarizeQuery runs the configured query once and groups the matches by work item
+// type and state. Only the type and state fields are requested, so this stays cheap on
+// queries that match thousands of items. Excluded types are left out like a migration
+// would, so Matching always equals the sum of TypeStates.
+func (c *Client) SummarizeQuery(ctx context.Context) (*QuerySummary, error) {
+	workItemIds, err := c.queryWorkItemIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{"System.WorkItemType", "System.State"}
+	var workItems []*models.WorkItem
+	for start := 0; start < len(workItemIds); start += statsBatchSize {
+		batch := workItemIds[start:min(start+statsBatchSize, len(workItemIds))]
+
+		response, err := retryThrottled(ctx, c.logger, c.observeWait, func() (*[]workitemtracking.WorkItem, error) {
+			return c.witClient.GetWorkItems(ctx, workitemtracking.GetWorkItemsArgs{
+				Project: &c.config.Project,
+				Ids:     &batch,
+				Fields:  &fields,
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get work item types: %w", err)
+		}
+
+		if response != nil {
+			for _, adoWorkItem := range *response {
+				workItems = append(workItems, c.convertToWorkItem(adoWorkItem))
+			}
+		}
+	}
+
+	matching := c.filterExcludedTypes(workItems)
+	return &QuerySummary{
+		Matching:   len(matching),
+		Excluded:   len(workItems) - len(matching),
+		TypeStates: tallyTypeStates(matching),
+	}, nil
+}
+
+// tallyTypeStates counts work items per type and state, sorted by count then name
+func tallyTypeStates(workItems []*models.WorkItem) []TypeStateCount {
+	type key struct{ workItemType, state string }
+	counts := map[key]int{}
+	for _, workItem := range workItems {
+		counts[key{workItem.GetWorkItemType(), workItem.GetState()}]++
+	}
+
+	tally := make([]TypeStateCount, 0, len(counts))
+	for k, count := range counts {
+		tally = append(tally, TypeStateCount{Type: k.workItemType, State: k.state, Count: count})
+	}
+
+	sort.Slice(tally, func(i, j int) bool {
+		if tally[i].Count != tally[j].Count {
+			return tally[i].Count > tally[j].Count
+		}
+		if tally[i].Type != tally[j].Type {
+			return tally[i].Type < tally[j].Type
+		}
+		return tally[i].State < tally[j].State
+	})
+
+	return tally
+}
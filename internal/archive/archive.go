@@ -0,0 +1,660 @@
+// Package archive reads and writes work item exports as newline-delimited JSON,
+// one record per work item, optionally compressed.
+//
+// Records are written in independently compressed segments (gzip members or
+// zstd frames). A crash while exporting only loses the segment being written,
+// and Resume truncates that partial segment so the export can continue where
+// it left off.
+//
+// Every archive starts with a header line carrying the schema version. Readers
+// accept archives written with the current or an older schema and reject newer
+// ones instead of silently misreading them. Archives written before headers
+// existed are read as version 0, which shares the version 1 record layout.
+//
+// The header also records the archive contents: which work item fields were
+// kept and whether comments were fetched. Resume only appends to an archive
+// with the same contents, so a single archive never mixes projections.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// Compression identifies the codec used for an archive file
+type Compression string
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// zstdSegmentMagic starts the zstd skippable frame written before each segment. Its payload
+// is the size of the segment frame, so Resume can find segment boundaries without decoding.
+// Decoders skip it, so the archive stays a standard zstd stream.
+const zstdSegmentMagic = 0x184D2A50
+
+// zstdSegmentHeaderSize is the skippable frame magic and size plus the 8 byte segment size
+const zstdSegmentHeaderSize = 16
+
+// DefaultSegmentSize is the number of records written per compressed segment
+const DefaultSegmentSize = 100
+
+// maxRecordSize bounds a single NDJSON line; work items with long histories can be large
+const maxRecordSize = 64 * 1024 * 1024
+
+// SchemaVersion is the archive schema written by this version of the tool.
+// Bump it whenever the record layout changes incompatibly and teach the reader
+// to upgrade records from older versions.
+const SchemaVersion = 1
+
+// formatName identifies adowi2gh archives in the header
+const formatName = "adowi2gh-archive"
+
+// ErrUnsupportedVersion is returned for archives written with a newer schema than this tool understands
+var ErrUnsupportedVersion = errors.New("unsupported archive schema version")
+
+// ErrContentsMismatch is returned when resuming an archive written with different contents
+var ErrContentsMismatch = errors.New("archive contents do not match")
+
+// Header is the first line of an archive
+type Header struct {
+	Format        string    `json:"format"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Contents      *Contents `json:"contents,omitempty"` // Missing in archives written before contents were recorded
+}
+
+// Contents describes what the work item records of an archive hold
+type Contents struct {
+	Fields   []string `json:"fields,omitempty"` // Field reference names kept on each work item, empty when all fields were kept
+	Comments bool     `json:"comments"`         // Whether work item comments were fetched
+}
+
+// Missing returns the fields that are not kept in the archive, in the given order
+func (c *Contents) Missing(fields []string) []string {
+	if len(c.Fields) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if !slices.Contains(c.Fields, field) {
+			missing = append(missing, field)
+		}
+	}
+
+	return missing
+}
+
+// equal reports whether both describe the same contents, ignoring the order of fields
+func (c *Contents) equal(other *Contents) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+
+	return c.Comments == other.Comments && slices.Equal(sortedFields(c.Fields), sortedFields(other.Fields))
+}
+
+func sortedFields(fields []string) []string {
+	sorted := slices.Clone(fields)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// Record is a single archive line, either the header or an archived work item
+type Record struct {
+	Header     *Header          `json:"header,omitempty"`
+	ExportedAt time.Time        `json:"exported_at"`
+	WorkItem   *models.WorkItem `json:"work_item,omitempty"`
+}
+
+// checkHeader verifies this tool can read an archive with the given header
+func checkHeader(header *Header) error {
+	if header.Format != formatName {
+		return fmt.Errorf("not an adowi2gh archive (format %q)", header.Format)
+	}
+
+	if header.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("%w: archive uses schema %d, this version supports up to %d; upgrade adowi2gh",
+			ErrUnsupportedVersion, header.SchemaVersion, SchemaVersion)
+	}
+
+	return nil
+}
+
+// CompressionFromPath picks the codec from the file extension (.gz for gzip,
+// .zst for zstd, anything else is stored uncompressed)
+func CompressionFromPath(path string) Compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".gzip":
+		return CompressionGzip
+	case ".zst", ".zstd":
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+// Writer appends work item records to an archive file
+type Writer struct {
+	file        *os.File
+	compression Compression
+	contents    Contents
+	segment     io.WriteCloser
+	pending     int
+	segmentSize int
+}
+
+// Create starts a new archive at path with the given contents, replacing any existing file
+func Create(path string, contents Contents) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file: %w", err)
+	}
+
+	writer := newWriter(file, CompressionFromPath(path), contents)
+	if err := writer.writeHeader(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// Resume opens an existing archive for appending and returns the IDs of the
+// work items it already holds. A partially written trailing segment is
+// discarded. If the archive does not exist a new one is created. An archive
+// written with different contents fails with ErrContentsMismatch.
+func Resume(path string, contents Contents) (*Writer, map[int]bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		writer, err := Create(path, contents)
+		return writer, map[int]bool{}, err
+	}
+
+	compression := CompressionFromPath(path)
+	ids, header, validSize, err := scan(path, compression)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header != nil {
+		if err := checkHeader(header); err != nil {
+			return nil, nil, err
+		}
+		// Appending newer records to an older archive would mix schemas
+		if header.SchemaVersion < SchemaVersion {
+			return nil, nil, fmt.Errorf("%w: cannot append to an archive with schema %d, start a new export",
+				ErrUnsupportedVersion, header.SchemaVersion)
+		}
+		if !header.Contents.equal(&contents) {
+			return nil, nil, fmt.Errorf("%w: %s was written with other fields or comments, start a new export",
+				ErrContentsMismatch, path)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive file: %w", err)
+	}
+
+	if err := file.Truncate(validSize); err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("failed to discard partial archive segment: %w", err)
+	}
+
+	if _, err := file.Seek(validSize, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("failed to seek archive file: %w", err)
+	}
+
+	writer := newWriter(file, compression, contents)
+	if validSize == 0 {
+		// Nothing survived, not even the header
+		if err := writer.writeHeader(); err != nil {
+			_ = file.Close()
+			return nil, nil, err
+		}
+	}
+
+	return writer, ids, nil
+}
+
+func newWriter(file *os.File, compression Compression, contents Contents) *Writer {
+	return &Writer{
+		file:        file,
+		compression: compression,
+		contents:    contents,
+		segmentSize: DefaultSegmentSize,
+	}
+}
+
+// writeHeader writes the header in its own segment so it is durable before any record
+func (w *Writer) writeHeader() error {
+	now := time.Now()
+	contents := w.contents
+	header := &Header{Format: formatName, SchemaVersion: SchemaVersion, CreatedAt: now, Contents: &contents}
+
+	if err := w.writeRecord(Record{Header: header, ExportedAt: now}); err != nil {
+		return fmt.Errorf("failed to write archive header: %w", err)
+	}
+
+	return w.Flush()
+}
+
+// Write appends a work item record to the archive
+func (w *Writer) Write(workItem *models.WorkItem) error {
+	if err := w.writeRecord(Record{ExportedAt: time.Now(), WorkItem: workItem}); err != nil {
+		return fmt.Errorf("failed to write work item %d: %w", workItem.ID, err)
+	}
+
+	w.pending++
+	if w.pending >= w.segmentSize {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush completes the current segment so every record written so far
+// survives a crash
+func (w *Writer) Flush() error {
+	if w.segment == nil {
+		return nil
+	}
+
+	if err := w.segment.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive segment: %w", err)
+	}
+	w.segment = nil
+	w.pending = 0
+
+	return w.file.Sync()
+}
+
+// Close flushes pending records and closes the archive file
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		_ = w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
+
+func (w *Writer) writeRecord(record Record) error {
+	if w.segment == nil {
+		w.segment = w.openSegment()
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.segment.Write(append(data, '\n'))
+	return err
+}
+
+func (w *Writer) openSegment() io.WriteCloser {
+	switch w.compression {
+	case CompressionGzip:
+		return gzip.NewWriter(w.file)
+	case CompressionZstd:
+		return &zstdSegment{file: w.file}
+	}
+
+	return nopWriteCloser{w.file}
+}
+
+// zstdSegment buffers a segment and writes it as one zstd frame, preceded by its size
+type zstdSegment struct {
+	file   io.Writer
+	buffer bytes.Buffer
+}
+
+func (s *zstdSegment) Write(p []byte) (int, error) {
+	return s.buffer.Write(p)
+}
+
+func (s *zstdSegment) Close() error {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	defer encoder.Close()
+
+	frame := encoder.EncodeAll(s.buffer.Bytes(), nil)
+
+	header := make([]byte, zstdSegmentHeaderSize)
+	binary.LittleEndian.PutUint32(header[0:], zstdSegmentMagic)
+	binary.LittleEndian.PutUint32(header[4:], 8)
+	binary.LittleEndian.PutUint64(header[8:], uint64(len(frame)))
+
+	_, err = s.file.Write(append(header, frame...))
+	return err
+}
+
+// Reader streams work item records from an archive file
+type Reader struct {
+	file         *os.File
+	decompressor io.ReadCloser
+	scanner      *bufio.Scanner
+	version      int
+	contents     *Contents
+	pending      *models.WorkItem
+}
+
+// Open opens an archive for streaming reads
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive file: %w", err)
+	}
+
+	var source io.ReadCloser = file
+	switch CompressionFromPath(path) {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(bufio.NewReader(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		source = gzipReader
+	case CompressionZstd:
+		zstdReader, err := zstd.NewReader(bufio.NewReader(file), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to read zstd archive: %w", err)
+		}
+		source = zstdReader.IOReadCloser()
+	}
+
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	reader := &Reader{
+		file:         file,
+		decompressor: source,
+		scanner:      scanner,
+	}
+
+	if err := reader.readHeader(); err != nil {
+		_ = reader.Close()
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// Version returns the schema version of the archive, 0 for archives written before headers
+func (r *Reader) Version() int {
+	return r.version
+}
+
+// Contents returns what the archive records hold, nil for archives that don't record it
+func (r *Reader) Contents() *Contents {
+	return r.contents
+}
+
+// readHeader checks the header on the first line. Legacy archives start with a
+// record instead, which is kept for the first call to Next.
+func (r *Reader) readHeader() error {
+	record, err := r.nextRecord()
+	if err != nil || record == nil {
+		return err
+	}
+
+	if record.Header == nil {
+		r.pending = record.WorkItem
+		return nil
+	}
+
+	if err := checkHeader(record.Header); err != nil {
+		return err
+	}
+	r.version = record.Header.SchemaVersion
+	r.contents = record.Header.Contents
+
+	return nil
+}
+
+// Next returns the next work item in the archive, or io.EOF once all records are read
+func (r *Reader) Next() (*models.WorkItem, error) {
+	if r.pending != nil {
+		workItem := r.pending
+		r.pending = nil
+		return workItem, nil
+	}
+
+	for {
+		record, err := r.nextRecord()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			return nil, io.EOF
+		}
+
+		if record.WorkItem != nil {
+			return record.WorkItem, nil
+		}
+	}
+}
+
+// nextRecord parses the next non-empty line, returning nil at the end of the archive
+func (r *Reader) nextRecord() (*Record, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		record := &Record{}
+		if err := json.Unmarshal(line, record); err != nil {
+			return nil, fmt.Errorf("failed to parse archive record: %w", err)
+		}
+
+		return record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Close releases the archive file
+func (r *Reader) Close() error {
+	if r.decompressor != r.file {
+		_ = r.decompressor.Close()
+	}
+
+	return r.file.Close()
+}
+
+// scan walks the archive segment by segment and returns the IDs of every
+// fully written record, the header if one survived, and the byte size of the
+// intact prefix
+func scan(path string, compression Compression) (map[int]bool, *Header, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to open archive file: %w", err)
+	}
+	defer file.Close()
+
+	ids := map[int]bool{}
+	var header *Header
+	switch compression {
+	case CompressionZstd:
+		return scanZstd(file)
+	case CompressionNone:
+		validSize, err := scanLines(file, ids, &header)
+		return ids, header, validSize, err
+	}
+
+	source := &countingReader{reader: bufio.NewReader(file)}
+	gzipReader, err := gzip.NewReader(source)
+	if errors.Is(err, io.EOF) {
+		return ids, nil, 0, nil
+	}
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read gzip archive: %w", err)
+	}
+
+	var validSize int64
+	for {
+		gzipReader.Multistream(false)
+
+		segmentIDs := map[int]bool{}
+		var segmentHeader *Header
+		if _, err := scanLines(gzipReader, segmentIDs, &segmentHeader); err != nil {
+			// Truncated segment, everything before it is intact
+			break
+		}
+
+		for id := range segmentIDs {
+			ids[id] = true
+		}
+		if segmentHeader != nil {
+			header = segmentHeader
+		}
+		validSize = source.count
+
+		if err := gzipReader.Reset(source); err != nil {
+			break
+		}
+	}
+
+	return ids, header, validSize, nil
+}
+
+// scanZstd walks the zstd segments using the size written before each of them
+func scanZstd(source io.Reader) (map[int]bool, *Header, int64, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer decoder.Close()
+
+	reader := bufio.NewReader(source)
+	ids := map[int]bool{}
+	var header *Header
+	var validSize int64
+	for {
+		segmentHeader := make([]byte, zstdSegmentHeaderSize)
+		if _, err := io.ReadFull(reader, segmentHeader); err != nil {
+			break
+		}
+		if binary.LittleEndian.Uint32(segmentHeader[0:]) != zstdSegmentMagic {
+			return nil, nil, 0, fmt.Errorf("failed to read zstd archive: segment %d bytes in has no size header", validSize)
+		}
+
+		frame := make([]byte, binary.LittleEndian.Uint64(segmentHeader[8:]))
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			// Truncated segment, everything before it is intact
+			break
+		}
+
+		content, err := decoder.DecodeAll(frame, nil)
+		if err != nil {
+			break
+		}
+
+		segmentIDs := map[int]bool{}
+		var segmentHeaderRecord *Header
+		if _, err := scanLines(bytes.NewReader(content), segmentIDs, &segmentHeaderRecord); err != nil {
+			break
+		}
+
+		for id := range segmentIDs {
+			ids[id] = true
+		}
+		if segmentHeaderRecord != nil {
+			header = segmentHeaderRecord
+		}
+		validSize += int64(zstdSegmentHeaderSize + len(frame))
+	}
+
+	return ids, header, validSize, nil
+}
+
+// scanLines collects record IDs and the header from NDJSON content and returns
+// the size of the content up to and including the last complete record
+func scanLines(source io.Reader, ids map[int]bool, header **Header) (int64, error) {
+	reader := bufio.NewReaderSize(source, 64*1024)
+	var consumed, validSize int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		consumed += int64(len(line))
+
+		if err == io.EOF {
+			// A trailing line without newline is a partially written record
+			return validSize, nil
+		}
+		if err != nil {
+			return validSize, err
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return validSize, nil
+		}
+
+		if record.Header != nil {
+			*header = record.Header
+		}
+		if record.WorkItem != nil {
+			ids[record.WorkItem.ID] = true
+		}
+		validSize = consumed
+	}
+}
+
+// countingReader tracks how many bytes were consumed from the underlying
+// reader. It implements io.ByteReader so gzip doesn't read ahead of a segment.
+type countingReader struct {
+	reader *bufio.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.reader.ReadByte()
+	if err == nil {
+		c.count++
+	}
+	return b, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,251 @@
+package archive
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWorkItem(id int) *models.WorkItem {
+	return &models.WorkItem{
+		ID: id,
+		Fields: map[string]interface{}{
+			"System.Title": "Work item",
+		},
+	}
+}
+
+func readAll(t *testing.T, path string) []int {
+	reader, err := Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var ids []int
+	for {
+		workItem, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, workItem.ID)
+	}
+
+	return ids
+}
+
+func writeItems(t *testing.T, writer *Writer, ids ...int) {
+	for _, id := range ids {
+		require.NoError(t, writer.Write(newWorkItem(id)))
+	}
+}
+
+func TestCompressionFromPath(t *testing.T) {
+	assert.Equal(t, CompressionGzip, CompressionFromPath("export.ndjson.gz"))
+	assert.Equal(t, CompressionGzip, CompressionFromPath("EXPORT.NDJSON.GZ"))
+	assert.Equal(t, CompressionZstd, CompressionFromPath("export.ndjson.zst"))
+	assert.Equal(t, CompressionNone, CompressionFromPath("export.ndjson"))
+	assert.Equal(t, CompressionNone, CompressionFromPath("export"))
+}
+
+func TestWriterReader(t *testing.T) {
+	for _, name := range []string{"items.ndjson", "items.ndjson.gz", "items.ndjson.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "nested", name)
+
+			writer, err := Create(path, Contents{})
+			require.NoError(t, err)
+			writer.segmentSize = 2
+			writeItems(t, writer, 1, 2, 3, 4, 5)
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, []int{1, 2, 3, 4, 5}, readAll(t, path))
+		})
+	}
+
+	t.Run("preserves work item content", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson.gz")
+
+		writer, err := Create(path, Contents{})
+		require.NoError(t, err)
+		workItem := newWorkItem(7)
+		workItem.Comments = []models.WorkItemComment{{ID: 1, Text: "hello"}}
+		require.NoError(t, writer.Write(workItem))
+		require.NoError(t, writer.Close())
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		read, err := reader.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "Work item", read.GetTitle())
+		require.Len(t, read.Comments, 1)
+		assert.Equal(t, "hello", read.Comments[0].Text)
+	})
+}
+
+func TestResume(t *testing.T) {
+	t.Run("creates missing archive", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson.gz")
+
+		writer, ids, err := Resume(path, Contents{})
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+		writeItems(t, writer, 1)
+		require.NoError(t, writer.Close())
+
+		assert.Equal(t, []int{1}, readAll(t, path))
+	})
+
+	for _, name := range []string{"items.ndjson", "items.ndjson.gz", "items.ndjson.zst"} {
+		t.Run("appends to "+name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			writer, err := Create(path, Contents{})
+			require.NoError(t, err)
+			writeItems(t, writer, 1, 2)
+			require.NoError(t, writer.Close())
+
+			writer, ids, err := Resume(path, Contents{})
+			require.NoError(t, err)
+			assert.Equal(t, map[int]bool{1: true, 2: true}, ids)
+			writeItems(t, writer, 3)
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, []int{1, 2, 3}, readAll(t, path))
+		})
+
+		t.Run("discards partial segment in "+name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			writer, err := Create(path, Contents{})
+			require.NoError(t, err)
+			writer.segmentSize = 2
+			writeItems(t, writer, 1, 2, 3, 4)
+			require.NoError(t, writer.Close())
+
+			intact, err := os.Stat(path)
+			require.NoError(t, err)
+
+			// Simulate a crash in the middle of the next segment
+			writer, _, err = Resume(path, Contents{})
+			require.NoError(t, err)
+			writeItems(t, writer, 5, 6)
+			require.NoError(t, writer.Close())
+			full, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Less(t, intact.Size()+10, full.Size())
+			require.NoError(t, os.Truncate(path, intact.Size()+10))
+
+			writer, ids, err := Resume(path, Contents{})
+			require.NoError(t, err)
+			assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true}, ids)
+			writeItems(t, writer, 5, 6)
+			require.NoError(t, writer.Close())
+
+			assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, readAll(t, path))
+		})
+	}
+}
+
+func TestContents(t *testing.T) {
+	t.Run("recorded in the header", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson.gz")
+		contents := Contents{Fields: []string{"System.Title", "System.State"}, Comments: true}
+
+		writer, err := Create(path, contents)
+		require.NoError(t, err)
+		writeItems(t, writer, 1)
+		require.NoError(t, writer.Close())
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		require.NotNil(t, reader.Contents())
+		assert.Equal(t, contents, *reader.Contents())
+		assert.Equal(t, []string{"System.Tags"}, reader.Contents().Missing([]string{"System.State", "System.Tags"}))
+	})
+
+	t.Run("all fields kept", func(t *testing.T) {
+		contents := Contents{}
+		assert.Empty(t, contents.Missing([]string{"System.Title"}))
+	})
+
+	t.Run("resume requires the same contents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson.gz")
+
+		writer, err := Create(path, Contents{Fields: []string{"System.Title", "System.State"}})
+		require.NoError(t, err)
+		writeItems(t, writer, 1)
+		require.NoError(t, writer.Close())
+
+		// Field order does not matter
+		writer, ids, err := Resume(path, Contents{Fields: []string{"System.State", "System.Title"}})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]bool{1: true}, ids)
+		require.NoError(t, writer.Close())
+
+		_, _, err = Resume(path, Contents{Fields: []string{"System.Title"}})
+		assert.ErrorIs(t, err, ErrContentsMismatch)
+		_, _, err = Resume(path, Contents{Fields: []string{"System.Title", "System.State"}, Comments: true})
+		assert.ErrorIs(t, err, ErrContentsMismatch)
+	})
+}
+
+func TestSchemaVersion(t *testing.T) {
+	t.Run("new archives carry the current version", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson.gz")
+
+		writer, err := Create(path, Contents{})
+		require.NoError(t, err)
+		writeItems(t, writer, 1)
+		require.NoError(t, writer.Close())
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		defer reader.Close()
+		assert.Equal(t, SchemaVersion, reader.Version())
+	})
+
+	t.Run("archives without header are read as version 0", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson")
+		legacy := `{"exported_at":"2025-01-01T00:00:00Z","work_item":{"id":1,"fields":{}}}` + "\n" +
+			`{"exported_at":"2025-01-01T00:00:00Z","work_item":{"id":2,"fields":{}}}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(legacy), 0600))
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		assert.Equal(t, 0, reader.Version())
+		require.NoError(t, reader.Close())
+
+		assert.Equal(t, []int{1, 2}, readAll(t, path))
+	})
+
+	t.Run("newer versions are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson")
+		future := `{"header":{"format":"adowi2gh-archive","schema_version":99,"created_at":"2030-01-01T00:00:00Z"},"exported_at":"2030-01-01T00:00:00Z"}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(future), 0600))
+
+		_, err := Open(path)
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+		_, _, err = Resume(path, Contents{})
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("foreign files are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "items.ndjson")
+		foreign := `{"header":{"format":"something-else","schema_version":1},"exported_at":"2030-01-01T00:00:00Z"}` + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(foreign), 0600))
+
+		_, err := Open(path)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+// Package audit writes an append-only JSONL log of the write operations a
+// migration performs, for change-management processes that need a record of
+// every change independent of the migration report.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Outcomes recorded for an operation
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry is a single audited write operation
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Service    string    `json:"service"`   // "github" or "azure_devops"
+	Operation  string    `json:"operation"` // e.g. "create_issue"
+	Endpoint   string    `json:"endpoint"`  // HTTP method and path
+	Target     string    `json:"target"`
+	WorkItemID int       `json:"work_item_id,omitempty"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Log appends entries to a JSONL file. A nil Log discards entries so callers
+// don't have to check whether auditing is enabled.
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Open opens path for appending, creating it and its directory when needed
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &Log{file: file}, nil
+}
+
+// Record appends entry to the log. The timestamp and outcome are filled in from
+// err when not set.
+func (l *Log) Record(entry Entry, err error) error {
+	if l == nil {
+		return nil
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = OutcomeSuccess
+		if err != nil {
+			entry.Outcome = OutcomeFailure
+		}
+	}
+	if err != nil && entry.Error == "" {
+		entry.Error = err.Error()
+	}
+
+	data, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", marshalErr)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes and closes the underlying file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+
+	return l.file.Close()
+}
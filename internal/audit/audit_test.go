@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+
+	return entries
+}
+
+func TestLog(t *testing.T) {
+	t.Run("appends across opens", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+
+		log, err := Open(path)
+		require.NoError(t, err)
+		require.NoError(t, log.Record(Entry{Service: "github", Operation: "create_issue", Target: "owner/repo#1", WorkItemID: 42}, nil))
+		require.NoError(t, log.Close())
+
+		log, err = Open(path)
+		require.NoError(t, err)
+		require.NoError(t, log.Record(Entry{Service: "github", Operation: "create_comment", Target: "owner/repo#1"}, errors.New("boom")))
+		require.NoError(t, log.Close())
+
+		entries := readEntries(t, path)
+		require.Len(t, entries, 2)
+
+		assert.Equal(t, "create_issue", entries[0].Operation)
+		assert.Equal(t, 42, entries[0].WorkItemID)
+		assert.Equal(t, OutcomeSuccess, entries[0].Outcome)
+		assert.False(t, entries[0].Timestamp.IsZero())
+
+		assert.Equal(t, OutcomeFailure, entries[1].Outcome)
+		assert.Equal(t, "boom", entries[1].Error)
+	})
+
+	t.Run("nil log discards entries", func(t *testing.T) {
+		var log *Log
+		assert.NoError(t, log.Record(Entry{Operation: "create_issue"}, nil))
+		assert.NoError(t, log.Close())
+	})
+}
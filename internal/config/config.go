@@ -5,6 +5,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.yaml.in/yaml/v4"
 )
@@ -13,13 +16,16 @@ type Config struct {
 	AzureDevOps AzureDevOpsConfig `yaml:"azure_devops"`
 	GitHub      GitHubConfig      `yaml:"github"`
 	Migration   MigrationConfig   `yaml:"migration"`
+	Reports     ReportsConfig     `yaml:"reports"`
+	Notify      NotifyConfig      `yaml:"notifications"`
 }
 
 type AzureDevOpsConfig struct {
-	OrganizationURL     string        `yaml:"organization_url"`
-	PersonalAccessToken string        `yaml:"personal_access_token"`
-	Project             string        `yaml:"project"`
-	Query               WorkItemQuery `yaml:"query"`
+	OrganizationURL       string        `yaml:"organization_url"`
+	PersonalAccessToken   string        `yaml:"personal_access_token"`
+	Project               string        `yaml:"project"`
+	Query                 WorkItemQuery `yaml:"query"`
+	MaxConcurrentRequests int           `yaml:"max_concurrent_requests"` // Parallel work item detail requests
 }
 
 type GitHubConfig struct {
@@ -38,25 +44,168 @@ type WorkItemQuery struct {
 	WorkItemTypes []string `yaml:"work_item_types"`
 	States        []string `yaml:"states"`
 	AreaPaths     []string `yaml:"area_paths"`
+	ExcludeTypes  []string `yaml:"exclude_types"` // Work item types never migrated, e.g. test artifacts
+	MinID         int      `yaml:"min_id"`        // Inclusive lower bound on work item IDs, 0 for none
+	MaxID         int      `yaml:"max_id"`        // Inclusive upper bound on work item IDs, 0 for none
+}
+
+// ParseIDRange parses a work item ID range such as "1000-2000". Either bound may be
+// omitted ("1000-" or "-2000") to leave that side open.
+func ParseIDRange(value string) (int, int, error) {
+	lower, upper, found := strings.Cut(strings.TrimSpace(value), "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid ID range %q, expected MIN-MAX", value)
+	}
+
+	var minID, maxID int
+	var err error
+	if lower = strings.TrimSpace(lower); lower != "" {
+		if minID, err = strconv.Atoi(lower); err != nil || minID <= 0 {
+			return 0, 0, fmt.Errorf("invalid ID range %q: lower bound must be a positive integer", value)
+		}
+	}
+	if upper = strings.TrimSpace(upper); upper != "" {
+		if maxID, err = strconv.Atoi(upper); err != nil || maxID <= 0 {
+			return 0, 0, fmt.Errorf("invalid ID range %q: upper bound must be a positive integer", value)
+		}
+	}
+
+	if minID == 0 && maxID == 0 {
+		return 0, 0, fmt.Errorf("invalid ID range %q, expected MIN-MAX", value)
+	}
+	if maxID > 0 && minID > maxID {
+		return 0, 0, fmt.Errorf("invalid ID range %q: lower bound is greater than upper bound", value)
+	}
+
+	return minID, maxID, nil
+}
+
+// InIDRange reports whether id falls within the configured MinID and MaxID bounds
+func (q *WorkItemQuery) InIDRange(id int) bool {
+	if q.MinID > 0 && id < q.MinID {
+		return false
+	}
+	if q.MaxID > 0 && id > q.MaxID {
+		return false
+	}
+
+	return true
+}
+
+// RangePath returns path with the ID range inserted before its extensions, e.g.
+// migration_checkpoint_1-5000.json or migration_dataset_5001-max.ndjson.gz, so runs over
+// different ranges never share a file. Without a range or a path, path is returned unchanged.
+func (q *WorkItemQuery) RangePath(path string) string {
+	if path == "" || (q.MinID == 0 && q.MaxID == 0) {
+		return path
+	}
+
+	lower, upper := "1", "max"
+	if q.MinID > 0 {
+		lower = strconv.Itoa(q.MinID)
+	}
+	if q.MaxID > 0 {
+		upper = strconv.Itoa(q.MaxID)
+	}
+
+	// The extensions start at the first dot that doesn't start a hidden file name
+	dir, name := filepath.Split(path)
+	start := 0
+	if strings.HasPrefix(name, ".") {
+		start = 1
+	}
+	stem, ext := name, ""
+	if i := strings.Index(name[start:], "."); i >= 0 {
+		stem, ext = name[:start+i], name[start+i:]
+	}
+
+	return dir + stem + "_" + lower + "-" + upper + ext
+}
+
+type ReportsConfig struct {
+	Directory string `yaml:"directory"`
+	Retention int    `yaml:"retention"` // Number of reports of this run name to keep, 0 keeps all
+	RunName   string `yaml:"run_name"`  // Prefix for report file names
+	AuditLog  string `yaml:"audit_log"` // Append-only JSONL log of write operations, empty disables it
+}
+
+type NotifyConfig struct {
+	Email EmailConfig `yaml:"email"`
+}
+
+// EmailConfig sends the run summary with the report attached when a run finishes.
+// Email is disabled when smtp_host is empty.
+type EmailConfig struct {
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"` // Defaults to 587 (STARTTLS)
+	Username string   `yaml:"username"`  // Empty for servers without authentication
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
 }
 
 type MigrationConfig struct {
 	BatchSize            int               `yaml:"batch_size"`
+	Concurrency          int               `yaml:"concurrency"` // Work items migrated in parallel within a batch
 	FieldMapping         FieldMapping      `yaml:"field_mapping"`
 	UserMapping          map[string]string `yaml:"user_mapping"`
+	UserMappingFallback  string            `yaml:"user_mapping_fallback"` // GitHub user assigned when the assignee has no user_mapping entry
 	DryRun               bool              `yaml:"dry_run"`
 	IncludeComments      bool              `yaml:"include_comments"`
+	CommentsSince        time.Time         `yaml:"comments_since"`        // Only migrate comments created on or after this date
+	CommentMaxAgeYears   int               `yaml:"comment_max_age_years"` // Only migrate comments from the last N years, 0 for no limit
 	ResumeFromCheckpoint bool              `yaml:"resume_from_checkpoint"`
+	RetryFailed          bool              `yaml:"retry_failed"`           // Only migrate items waiting in the checkpoint retry queue
+	MaxRetryAttempts     int               `yaml:"max_retry_attempts"`     // Failed attempts before an item is escalated, 0 retries forever
+	CheckpointPath       string            `yaml:"checkpoint_path"`        // May point at a shared location; a lock file is kept next to it
+	LockTTL              time.Duration     `yaml:"lock_ttl"`               // Lease duration of the run lock, renewed every third of the lease
+	TitleCollisionPolicy string            `yaml:"title_collision_policy"` // "create", "skip" or "link"; empty disables the check
+	SkipClosedBefore     time.Time         `yaml:"skip_closed_before"`     // Exclude closed work items closed before this date
+	RetentionArchive     string            `yaml:"retention_archive"`      // Archive excluded work items to this path instead of dropping them
+	MaxRunDuration       time.Duration     `yaml:"max_run_duration"`       // Stop after this long, 0 for no limit
+	MaxItemDuration      time.Duration     `yaml:"max_item_duration"`      // Stop when a single work item takes longer, 0 for no limit
+	DatasetPath          string            `yaml:"dataset_path"`           // Work items fetched from ADO, read by the publish phase
+	FetchOnly            bool              `yaml:"fetch_only"`             // Only fetch work items into the dataset
+	PublishOnly          bool              `yaml:"publish_only"`           // Only publish the dataset to GitHub
+	TraceMapping         bool              `yaml:"trace_mapping"`          // Add the mapping trace to dry run report mappings
 }
 
 type FieldMapping struct {
 	StateMapping         map[string]string   `yaml:"state_mapping"`
+	StateLocale          string              `yaml:"state_locale"` // Built-in state names to classify in addition to English: de, fr, es or pt
 	LabelMapping         map[string][]string `yaml:"label_mapping"`
 	TypeMapping          map[string][]string `yaml:"type_mapping"`
 	PriorityMapping      map[string][]string `yaml:"priority_mapping"`
 	TimeZone             string              `yaml:"time_zone"`
+	DateFormat           string              `yaml:"date_format"`     // Go layout or strftime-style format for rendered dates
+	NumberComments       bool                `yaml:"number_comments"` // Prefix comments with "Comment #N of M"
+	IncludeEngagement    bool                `yaml:"include_engagement_metadata"`
+	FieldApplicability   map[string][]string `yaml:"field_applicability"` // Field reference name -> work item types the field is mapped for
 	IncludeSeverityLabel bool                `yaml:"include_severity_label"`
 	IncludeAreaPathLabel bool                `yaml:"include_area_path_label"`
+	LabelDelimiter       string              `yaml:"label_delimiter"`     // Separator for generated severity and area labels (default ":")
+	LabelAliases         map[string]string   `yaml:"label_aliases"`       // Generated label -> existing repository label to use instead
+	IncludeCMMIFields    bool                `yaml:"include_cmmi_fields"` // Render CMMI process fields as body sections
+	NumericLabelBuckets  []NumericLabelRule  `yaml:"numeric_label_buckets"`
+	BooleanLabelMapping  map[string]string   `yaml:"boolean_label_mapping"`    // Field reference name -> label added when the field is true
+	IncludeProvenance    bool                `yaml:"include_provenance_label"` // Label every created issue as migrated
+	ProvenanceLabel      string              `yaml:"provenance_label"`
+	RunLabel             string              `yaml:"run_label"` // Label identifying a migration wave
+	IncludeTypeEmoji     bool                `yaml:"include_type_emoji"`
+	TypeEmoji            map[string]string   `yaml:"type_emoji"`   // Work item type -> title prefix, overrides the built-in defaults
+	DebugFields          []string            `yaml:"debug_fields"` // Fields whose raw values are logged per work item at debug level
+}
+
+// NumericLabelRule maps a numeric field to a label using ordered buckets
+type NumericLabelRule struct {
+	Field   string        `yaml:"field"`
+	Buckets []LabelBucket `yaml:"buckets"`
+}
+
+// LabelBucket matches values up to and including Max. A bucket without Max matches any value.
+type LabelBucket struct {
+	Max   *float64 `yaml:"max"`
+	Label string   `yaml:"label"`
 }
 
 func LoadConfig(configPath string) (*Config, error) {
@@ -87,10 +236,17 @@ func LoadConfig(configPath string) (*Config, error) {
 
 func setDefaults(config *Config) {
 	config.Migration.BatchSize = 50
+	config.Migration.Concurrency = 1
 	config.Migration.DryRun = false
 	config.Migration.IncludeComments = true
 	config.Migration.ResumeFromCheckpoint = false
+	config.Migration.MaxRetryAttempts = 3
+	config.Migration.CheckpointPath = "./migration_checkpoint.json"
+	config.Migration.LockTTL = 15 * time.Minute
+	config.Migration.DatasetPath = "./migration_dataset.ndjson.gz"
 	config.GitHub.BaseURL = "https://api.github.com"
+	config.AzureDevOps.MaxConcurrentRequests = 4
+	config.Reports.Directory = "./reports"
 }
 
 func validateConfig(config *Config) error {
@@ -106,6 +262,18 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("azure_devops.project is required")
 	}
 
+	if config.AzureDevOps.Query.MinID < 0 || config.AzureDevOps.Query.MaxID < 0 {
+		return fmt.Errorf("azure_devops.query.min_id and max_id must not be negative")
+	}
+
+	if config.AzureDevOps.Query.MaxID > 0 && config.AzureDevOps.Query.MinID > config.AzureDevOps.Query.MaxID {
+		return fmt.Errorf("azure_devops.query.min_id must not be greater than max_id")
+	}
+
+	if config.AzureDevOps.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("azure_devops.max_concurrent_requests must not be negative")
+	}
+
 	if config.GitHub.Token == "" && config.GitHub.AppCertificatePath == "" {
 		return fmt.Errorf("github.token or github.app_certificate_path is required")
 	}
@@ -126,6 +294,62 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("migration.batch_size must be greater than 0")
 	}
 
+	switch config.Migration.TitleCollisionPolicy {
+	case "", "create", "skip", "link":
+	default:
+		return fmt.Errorf("migration.title_collision_policy must be one of create, skip or link")
+	}
+
+	switch config.Migration.FieldMapping.StateLocale {
+	case "", "en", "de", "fr", "es", "pt":
+	default:
+		return fmt.Errorf("migration.field_mapping.state_locale must be one of en, de, fr, es or pt")
+	}
+
+	if config.Migration.FetchOnly && config.Migration.PublishOnly {
+		return fmt.Errorf("migration.fetch_only and publish_only cannot both be set")
+	}
+
+	if config.Migration.MaxRunDuration < 0 || config.Migration.MaxItemDuration < 0 {
+		return fmt.Errorf("migration.max_run_duration and max_item_duration must not be negative")
+	}
+
+	if config.Migration.LockTTL < 0 {
+		return fmt.Errorf("migration.lock_ttl must not be negative")
+	}
+
+	if config.Migration.Concurrency < 0 {
+		return fmt.Errorf("migration.concurrency must not be negative")
+	}
+
+	if config.Migration.CommentMaxAgeYears < 0 {
+		return fmt.Errorf("migration.comment_max_age_years must not be negative")
+	}
+
+	if config.Migration.MaxRetryAttempts < 0 {
+		return fmt.Errorf("migration.max_retry_attempts must not be negative")
+	}
+
+	if config.Reports.Retention < 0 {
+		return fmt.Errorf("reports.retention must not be negative")
+	}
+
+	if email := config.Notify.Email; email.SMTPHost != "" && (email.From == "" || len(email.To) == 0) {
+		return fmt.Errorf("notifications.email.from and to are required when smtp_host is set")
+	}
+
+	for i, rule := range config.Migration.FieldMapping.NumericLabelBuckets {
+		if rule.Field == "" {
+			return fmt.Errorf("migration.field_mapping.numeric_label_buckets[%d].field is required", i)
+		}
+
+		for j, bucket := range rule.Buckets {
+			if bucket.Label == "" {
+				return fmt.Errorf("migration.field_mapping.numeric_label_buckets[%d].buckets[%d].label is required", i, j)
+			}
+		}
+	}
+
 	return nil
 }
 
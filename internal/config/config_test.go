@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,7 +38,9 @@ migration:
   batch_size: 25
   dry_run: true
   include_comments: false
+  comments_since: 2021-06-01
   resume_from_checkpoint: true
+  lock_ttl: 30m
   field_mapping:
     state_mapping:
       "New": "open"
@@ -69,6 +72,8 @@ migration:
 		assert.Equal(t, 25, config.Migration.BatchSize)
 		assert.True(t, config.Migration.DryRun)
 		assert.False(t, config.Migration.IncludeComments)
+		assert.Equal(t, 30*time.Minute, config.Migration.LockTTL)
+		assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), config.Migration.CommentsSince)
 	})
 }
 
@@ -211,6 +216,247 @@ func TestValidateConfig(t *testing.T) {
 			expectError: true,
 			errorMsg:    "github.repository is required",
 		},
+		{
+			name: "negative max concurrent requests",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:       "https://dev.azure.com/org",
+					PersonalAccessToken:   "pat123",
+					Project:               "project",
+					MaxConcurrentRequests: -1,
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize: 50,
+				},
+			},
+			expectError: true,
+			errorMsg:    "azure_devops.max_concurrent_requests must not be negative",
+		},
+		{
+			name: "numeric label bucket without label",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize: 50,
+					FieldMapping: FieldMapping{
+						NumericLabelBuckets: []NumericLabelRule{
+							{Field: "Microsoft.VSTS.Scheduling.StoryPoints", Buckets: []LabelBucket{{}}},
+						},
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.field_mapping.numeric_label_buckets[0].buckets[0].label is required",
+		},
+		{
+			name: "inverted ID range",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+					Query: WorkItemQuery{
+						MinID: 2000,
+						MaxID: 1000,
+					},
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize: 50,
+				},
+			},
+			expectError: true,
+			errorMsg:    "azure_devops.query.min_id must not be greater than max_id",
+		},
+		{
+			name: "invalid title collision policy",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:            50,
+					TitleCollisionPolicy: "merge",
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.title_collision_policy must be one of create, skip or link",
+		},
+		{
+			name: "email without recipients",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{BatchSize: 50},
+				Notify: NotifyConfig{
+					Email: EmailConfig{SMTPHost: "smtp.example.com", From: "migration@example.com"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "notifications.email.from and to are required when smtp_host is set",
+		},
+		{
+			name: "unsupported state locale",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:    50,
+					FieldMapping: FieldMapping{StateLocale: "ja"},
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.field_mapping.state_locale must be one of en, de, fr, es or pt",
+		},
+		{
+			name: "negative item duration",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:       50,
+					MaxItemDuration: -time.Second,
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.max_run_duration and max_item_duration must not be negative",
+		},
+		{
+			name: "fetch only and publish only",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:   50,
+					FetchOnly:   true,
+					PublishOnly: true,
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.fetch_only and publish_only cannot both be set",
+		},
+		{
+			name: "negative max retry attempts",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:        50,
+					MaxRetryAttempts: -1,
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.max_retry_attempts must not be negative",
+		},
+		{
+			name: "negative concurrency",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize:   50,
+					Concurrency: -1,
+				},
+			},
+			expectError: true,
+			errorMsg:    "migration.concurrency must not be negative",
+		},
+		{
+			name: "negative report retention",
+			config: &Config{
+				AzureDevOps: AzureDevOpsConfig{
+					OrganizationURL:     "https://dev.azure.com/org",
+					PersonalAccessToken: "pat123",
+					Project:             "project",
+				},
+				GitHub: GitHubConfig{
+					Token:      "token123",
+					Owner:      "owner",
+					Repository: "repo",
+				},
+				Migration: MigrationConfig{
+					BatchSize: 50,
+				},
+				Reports: ReportsConfig{
+					Retention: -1,
+				},
+			},
+			expectError: true,
+			errorMsg:    "reports.retention must not be negative",
+		},
 		{
 			name: "invalid batch size",
 			config: &Config{
@@ -246,6 +492,71 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestParseIDRange(t *testing.T) {
+	tests := []struct {
+		value       string
+		minID       int
+		maxID       int
+		expectError bool
+	}{
+		{value: "1000-2000", minID: 1000, maxID: 2000},
+		{value: " 1000 - 2000 ", minID: 1000, maxID: 2000},
+		{value: "1000-", minID: 1000},
+		{value: "-2000", maxID: 2000},
+		{value: "1000", expectError: true},
+		{value: "-", expectError: true},
+		{value: "abc-2000", expectError: true},
+		{value: "0-10", expectError: true},
+		{value: "2000-1000", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			minID, maxID, err := ParseIDRange(tt.value)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.minID, minID)
+			assert.Equal(t, tt.maxID, maxID)
+		})
+	}
+}
+
+func TestWorkItemQuery_RangePath(t *testing.T) {
+	tests := []struct {
+		query WorkItemQuery
+		path  string
+		want  string
+	}{
+		{query: WorkItemQuery{MinID: 1, MaxID: 5000}, path: "./migration_checkpoint.json", want: "./migration_checkpoint_1-5000.json"},
+		{query: WorkItemQuery{MinID: 5001}, path: "./migration_dataset.ndjson.gz", want: "./migration_dataset_5001-max.ndjson.gz"},
+		{query: WorkItemQuery{MaxID: 2000}, path: "/shared/run/.checkpoint.json", want: "/shared/run/.checkpoint_1-2000.json"},
+		{query: WorkItemQuery{MinID: 10, MaxID: 20}, path: "checkpoint", want: "checkpoint_10-20"},
+		{query: WorkItemQuery{}, path: "./migration_checkpoint.json", want: "./migration_checkpoint.json"},
+		{query: WorkItemQuery{MinID: 10}, path: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, filepath.FromSlash(tt.want), tt.query.RangePath(filepath.FromSlash(tt.path)))
+		})
+	}
+}
+
+func TestWorkItemQuery_InIDRange(t *testing.T) {
+	query := &WorkItemQuery{MinID: 1000, MaxID: 2000}
+	assert.False(t, query.InIDRange(999))
+	assert.True(t, query.InIDRange(1000))
+	assert.True(t, query.InIDRange(2000))
+	assert.False(t, query.InIDRange(2001))
+
+	open := &WorkItemQuery{}
+	assert.True(t, open.InIDRange(1))
+}
+
 func TestSaveConfig(t *testing.T) {
 	t.Run("save config to file", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -321,8 +632,15 @@ func TestSetDefaults(t *testing.T) {
 	setDefaults(config)
 
 	assert.Equal(t, 50, config.Migration.BatchSize)
+	assert.Equal(t, 1, config.Migration.Concurrency)
 	assert.False(t, config.Migration.DryRun)
 	assert.True(t, config.Migration.IncludeComments)
 	assert.False(t, config.Migration.ResumeFromCheckpoint)
+	assert.Equal(t, 3, config.Migration.MaxRetryAttempts)
+	assert.Equal(t, "./migration_checkpoint.json", config.Migration.CheckpointPath)
+	assert.Equal(t, 15*time.Minute, config.Migration.LockTTL)
+	assert.Equal(t, "./migration_dataset.ndjson.gz", config.Migration.DatasetPath)
 	assert.Equal(t, "https://api.github.com", config.GitHub.BaseURL)
+	assert.Equal(t, 4, config.AzureDevOps.MaxConcurrentRequests)
+	assert.Equal(t, "./reports", config.Reports.Directory)
 }
@@ -0,0 +1,320 @@
+// Package demo provides in-memory stand-ins for Azure DevOps and GitHub loaded with
+// bundled sample work items, so a full migration can be run locally without tokens.
+package demo
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	gh "github.com/google/go-github/v74/github"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/github"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// Target repository and source project shown in demo output
+const (
+	Organization = "fabrikam"
+	Project      = "Fabrikam"
+	Owner        = "fabrikam"
+	Repository   = "sample-repo"
+)
+
+//go:embed samples.json
+var samples []byte
+
+// userMapping maps the sample ADO users to GitHub logins. Chris is mapped to a login that
+// isn't a collaborator and Dana isn't mapped, to show how both are reported.
+var userMapping = map[string]string{
+	"ana@fabrikam.com":   "ana-lima",
+	"ben@fabrikam.com":   "bortiz",
+	"chris@fabrikam.com": "cpark",
+}
+
+// collaborators are the logins the demo repository accepts as assignees
+var collaborators = map[string]bool{
+	"ana-lima": true,
+	"bortiz":   true,
+}
+
+// Configure points cfg at the demo project and repository and keeps every file the run
+// writes (dataset, checkpoint, reports) under dir.
+func Configure(cfg *config.Config, dir string) {
+	cfg.AzureDevOps.OrganizationURL = "https://dev.azure.com/" + Organization
+	cfg.AzureDevOps.Project = Project
+	cfg.GitHub.Owner = Owner
+	cfg.GitHub.Repository = Repository
+
+	cfg.Migration.UserMapping = map[string]string{}
+	for adoUser, login := range userMapping {
+		cfg.Migration.UserMapping[adoUser] = login
+	}
+	cfg.Migration.DatasetPath = filepath.Join(dir, "migration_dataset.ndjson.gz")
+	cfg.Migration.CheckpointPath = filepath.Join(dir, "migration_checkpoint.json")
+	cfg.Reports.Directory = filepath.Join(dir, "reports")
+}
+
+// loadSamples decodes a fresh copy of the bundled work items
+func loadSamples() ([]*models.WorkItem, error) {
+	var workItems []*models.WorkItem
+	if err := json.Unmarshal(samples, &workItems); err != nil {
+		return nil, fmt.Errorf("failed to read sample work items: %w", err)
+	}
+
+	return workItems, nil
+}
+
+// Source serves the sample work items in place of Azure DevOps
+type Source struct {
+	retainedFields []string
+}
+
+func NewSource() *Source {
+	return &Source{}
+}
+
+func (s *Source) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+// SetFieldProjection limits the fields kept on returned work items, like ado.Client does
+func (s *Source) SetFieldProjection(fields []string) {
+	s.retainedFields = fields
+}
+
+// SetRateLimitObserver does nothing, the demo is never throttled
+func (s *Source) SetRateLimitObserver(observe func(time.Duration)) {}
+
+// QueryWorkItemIDs returns the IDs of the sample work items
+func (s *Source) QueryWorkItemIDs(ctx context.Context) ([]int, error) {
+	workItems, err := loadSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(workItems))
+	for i, workItem := range workItems {
+		ids[i] = workItem.ID
+	}
+
+	return ids, nil
+}
+
+// StreamWorkItems passes the requested sample work items to handle in a single batch.
+// Comments are left out and served by GetWorkItemComments, as Azure DevOps does.
+func (s *Source) StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
+	workItems, err := loadSamples()
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]*models.WorkItem, len(workItems))
+	for _, workItem := range workItems {
+		byID[workItem.ID] = workItem
+	}
+
+	batch := make([]*models.WorkItem, 0, len(workItemIds))
+	for _, id := range workItemIds {
+		workItem, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("work item %d not found", id)
+		}
+
+		workItem.Comments = nil
+		if s.retainedFields != nil {
+			workItem.RetainFields(s.retainedFields)
+		}
+		batch = append(batch, workItem)
+	}
+
+	return handle(batch)
+}
+
+func (s *Source) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
+	workItems, err := loadSamples()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, workItem := range workItems {
+		if workItem.ID == workItemID {
+			return workItem.Comments, nil
+		}
+	}
+
+	return nil, fmt.Errorf("work item %d not found", workItemID)
+}
+
+// Tracker keeps created issues in memory in place of a GitHub repository
+type Tracker struct {
+	mu       sync.Mutex
+	issues   []*models.GitHubIssue
+	requests map[string]int
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{requests: map[string]int{}}
+}
+
+func (t *Tracker) count(category string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests[category]++
+}
+
+func (t *Tracker) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+// CreateIssue stores issue under the next number. Like GitHub, it fails when an
+// assignee can't be assigned in the repository.
+func (t *Tracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	t.count(github.RequestCategoryREST)
+
+	for _, assignee := range issue.Assignees {
+		if !collaborators[strings.ToLower(assignee)] {
+			return nil, fmt.Errorf("failed to create issue: %w", &gh.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusUnprocessableEntity},
+				Message:  "Validation Failed",
+				Errors:   []gh.Error{{Resource: "Issue", Field: "assignees", Code: "invalid", Message: assignee + " can't be assigned"}},
+			})
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	created := *issue
+	created.Number = len(t.issues) + 1
+	created.State = "open"
+	created.Labels = append([]string{}, issue.Labels...)
+	created.Comments = nil
+	created.CreatedAt = &now
+	created.UpdatedAt = &now
+	t.issues = append(t.issues, &created)
+
+	result := created
+	return &result, nil
+}
+
+func (t *Tracker) CreateIssueComment(ctx context.Context, issueNumber int, comment *models.GitHubComment) error {
+	t.count(github.RequestCategoryREST)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issue, err := t.issue(issueNumber)
+	if err != nil {
+		return err
+	}
+	issue.Comments = append(issue.Comments, *comment)
+
+	return nil
+}
+
+func (t *Tracker) UpdateIssueState(ctx context.Context, issueNumber int, state string) error {
+	t.count(github.RequestCategoryREST)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issue, err := t.issue(issueNumber)
+	if err != nil {
+		return err
+	}
+	issue.State = state
+
+	return nil
+}
+
+// issue returns the stored issue with number. Callers hold mu.
+func (t *Tracker) issue(number int) (*models.GitHubIssue, error) {
+	if number < 1 || number > len(t.issues) {
+		return nil, fmt.Errorf("issue #%d not found in %s/%s", number, Owner, Repository)
+	}
+
+	return t.issues[number-1], nil
+}
+
+// SearchIssues returns the issues already created for workItemID
+func (t *Tracker) SearchIssues(ctx context.Context, workItemID int) ([]*gh.Issue, error) {
+	return t.search(func(issue *models.GitHubIssue) bool { return issue.SourceWIID == workItemID }), nil
+}
+
+// SearchIssuesByTitle returns the issues with exactly title
+func (t *Tracker) SearchIssuesByTitle(ctx context.Context, title string) ([]*gh.Issue, error) {
+	return t.search(func(issue *models.GitHubIssue) bool { return issue.Title == title }), nil
+}
+
+func (t *Tracker) search(match func(*models.GitHubIssue) bool) []*gh.Issue {
+	t.count(github.RequestCategorySearch)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var found []*gh.Issue
+	for _, issue := range t.issues {
+		if match(issue) {
+			found = append(found, &gh.Issue{
+				Number: gh.Ptr(issue.Number),
+				Title:  gh.Ptr(issue.Title),
+				Body:   gh.Ptr(issue.Body),
+				State:  gh.Ptr(issue.State),
+			})
+		}
+	}
+
+	return found
+}
+
+func (t *Tracker) IsAssignable(ctx context.Context, login string) (bool, error) {
+	t.count(github.RequestCategoryREST)
+	return collaborators[strings.ToLower(login)], nil
+}
+
+// ValidateLabels accepts every label, GitHub creates missing ones
+func (t *Tracker) ValidateLabels(ctx context.Context, labels []string) error {
+	return nil
+}
+
+func (t *Tracker) RequestCounts() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int, len(t.requests))
+	for category, count := range t.requests {
+		counts[category] = count
+	}
+
+	return counts
+}
+
+// RemainingBudget reports the full hourly budget of an authenticated GitHub user
+func (t *Tracker) RemainingBudget(ctx context.Context) (*github.RateLimitBudget, error) {
+	return &github.RateLimitBudget{Core: 5000, Search: 30, GraphQL: 5000}, nil
+}
+
+// SetRateLimitObserver does nothing, the demo is never rate limited
+func (t *Tracker) SetRateLimitObserver(observe func(time.Duration)) {}
+
+// Issues returns the created issues ordered by number
+func (t *Tracker) Issues() []models.GitHubIssue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issues := make([]models.GitHubIssue, 0, len(t.issues))
+	for _, issue := range t.issues {
+		issues = append(issues, *issue)
+	}
+
+	return issues
+}
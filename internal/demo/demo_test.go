@@ -0,0 +1,79 @@
+package demo
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/migration"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+func TestDemoDryRun(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Migration.DryRun = true
+	cfg.Migration.IncludeComments = true
+	Configure(cfg, t.TempDir())
+
+	logger := slog.New(slog.DiscardHandler)
+	engine := migration.NewEngine(NewSource(), NewTracker(), migration.NewMapper(&cfg.Migration, logger), &cfg.Migration, logger)
+
+	report, err := engine.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, report.TotalWorkItems)
+	assert.Equal(t, 5, report.SuccessfulCount)
+	assert.Equal(t, 1, report.FailedCount)
+	assert.Equal(t, map[string]int{"cpark": 1}, report.Breakdown.ByRejectedAssignee)
+}
+
+func TestSourceComments(t *testing.T) {
+	source := NewSource()
+
+	ids, err := source.QueryWorkItemIDs(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, ids)
+
+	var workItems []*models.WorkItem
+	err = source.StreamWorkItems(context.Background(), ids, func(batch []*models.WorkItem) error {
+		workItems = append(workItems, batch...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, workItems, len(ids))
+	assert.Empty(t, workItems[0].Comments)
+
+	comments, err := source.GetWorkItemComments(context.Background(), 101)
+	require.NoError(t, err)
+	assert.Len(t, comments, 2)
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewTracker()
+
+	created, err := tracker.CreateIssue(ctx, &models.GitHubIssue{Title: "First", SourceWIID: 7, Assignees: []string{"ana-lima"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, created.Number)
+
+	_, err = tracker.CreateIssue(ctx, &models.GitHubIssue{Title: "Second", SourceWIID: 8, Assignees: []string{"cpark"}})
+	assert.ErrorContains(t, err, "422")
+
+	require.NoError(t, tracker.UpdateIssueState(ctx, 1, "closed"))
+	require.NoError(t, tracker.CreateIssueComment(ctx, 1, &models.GitHubComment{Body: "hello"}))
+
+	found, err := tracker.SearchIssues(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, 1, found[0].GetNumber())
+
+	issues := tracker.Issues()
+	require.Len(t, issues, 1)
+	assert.Equal(t, "closed", issues[0].State)
+	assert.Len(t, issues[0].Comments, 1)
+	assert.Equal(t, map[string]int{"rest": 4, "search": 1}, tracker.RequestCounts())
+}
@@ -0,0 +1,56 @@
+// Package filename makes generated file names safe to create on every platform
+package filename
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// MaxLength caps a sanitized name, in UTF-16 code units as counted by NTFS, leaving
+// room for suffixes such as timestamps and extensions within the 255 unit limit
+// of a single path component.
+const MaxLength = 200
+
+// reservedNames are device names Windows refuses as file names, with or without an extension
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Sanitize returns name with characters Windows rejects (<>:"/\|?* and control
+// characters) replaced by underscores, trailing dots and spaces removed, reserved
+// device names prefixed and the result truncated to MaxLength. Other unicode is kept.
+func Sanitize(name string) string {
+	name = strings.ToValidUTF8(name, "_")
+
+	var builder strings.Builder
+	length := 0
+	for _, r := range name {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || unicode.IsControl(r) {
+			r = '_'
+		}
+
+		size := utf16.RuneLen(r)
+		if length+size > MaxLength {
+			break
+		}
+		length += size
+		builder.WriteRune(r)
+	}
+
+	sanitized := strings.TrimRight(builder.String(), ". ")
+	if sanitized == "" {
+		return "_"
+	}
+
+	stem, _, _ := strings.Cut(sanitized, ".")
+	if reservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		sanitized = "_" + sanitized
+	}
+
+	return sanitized
+}
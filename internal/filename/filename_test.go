@@ -0,0 +1,43 @@
+package filename
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "nightly_run", "nightly_run"},
+		{"invalid characters", `spec: v2/final?*"<x>|\`, "spec_ v2_final____x___"},
+		{"control characters", "a\tb\x00c", "a_b_c"},
+		{"unicode kept", "привет 日本 ✓", "привет 日本 ✓"},
+		{"trailing dots and spaces", "report. . ", "report"},
+		{"reserved name", "con", "_con"},
+		{"reserved name with extension", "LPT1.json", "_LPT1.json"},
+		{"reserved prefix only", "console", "console"},
+		{"empty", "", "_"},
+		{"only dots", "...", "_"},
+		{"invalid utf-8", "a\xffb", "a_b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Sanitize(tt.input))
+		})
+	}
+}
+
+func TestSanitize_Truncates(t *testing.T) {
+	assert.Len(t, Sanitize(strings.Repeat("a", 300)), MaxLength)
+
+	// Characters outside the BMP take two UTF-16 units and are never split
+	sanitized := Sanitize(strings.Repeat("😀", 150))
+	assert.Len(t, utf16.Encode([]rune(sanitized)), MaxLength)
+}
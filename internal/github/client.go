@@ -5,19 +5,38 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v74/github"
 	"golang.org/x/oauth2"
 
+	"github.com/jlucaspains/adowi2gh/internal/audit"
 	"github.com/jlucaspains/adowi2gh/internal/config"
 	"github.com/jlucaspains/adowi2gh/internal/models"
 )
 
 type Client struct {
-	client *github.Client
-	config *config.GitHubConfig
-	logger *slog.Logger
+	client   *github.Client
+	config   *config.GitHubConfig
+	logger   *slog.Logger
+	auditLog *audit.Log
+	requests *countingTransport
+	repoMu   sync.RWMutex // Guards config.Owner and config.Repository, which change when a rename is followed
+
+	observeWait func(time.Duration)
+
+	assignableMu sync.Mutex
+	assignable   map[string]bool // Login -> whether it can be assigned issues in the repository
+}
+
+// RateLimitBudget is the remaining hourly budget per rate limit category
+type RateLimitBudget struct {
+	Core    int
+	Search  int
+	GraphQL int
 }
 
 func NewClient(cfg *config.GitHubConfig, logger *slog.Logger) (*Client, error) {
@@ -56,6 +75,11 @@ func NewClient(cfg *config.GitHubConfig, logger *slog.Logger) (*Client, error) {
 		tc = &http.Client{Transport: itr}
 	}
 
+	// Count every request, including the ones made by the installation transport
+	requests := newCountingTransport(tc.Transport)
+	tc.Transport = requests
+	tc.CheckRedirect = checkRedirect
+
 	var githubClient *github.Client
 	if cfg.BaseURL != "" && cfg.BaseURL != "https://api.github.com" {
 		// GitHub Enterprise
@@ -65,17 +89,80 @@ func NewClient(cfg *config.GitHubConfig, logger *slog.Logger) (*Client, error) {
 	}
 
 	return &Client{
-		client: githubClient,
-		config: cfg,
-		logger: logger,
+		client:   githubClient,
+		config:   cfg,
+		logger:   logger,
+		requests: requests,
 	}, nil
 }
 
+// RequestCounts returns how many requests were sent so far per category (rest, graphql, search)
+func (c *Client) RequestCounts() map[string]int {
+	return c.requests.snapshot()
+}
+
+// RemainingBudget returns the requests left in the current rate limit windows.
+// Checking the rate limit does not count against it.
+func (c *Client) RemainingBudget(ctx context.Context) (*RateLimitBudget, error) {
+	limits, _, err := c.client.RateLimit.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate limits: %w", err)
+	}
+
+	budget := &RateLimitBudget{}
+	if limits.Core != nil {
+		budget.Core = limits.Core.Remaining
+	}
+	if limits.Search != nil {
+		budget.Search = limits.Search.Remaining
+	}
+	if limits.GraphQL != nil {
+		budget.GraphQL = limits.GraphQL.Remaining
+	}
+
+	return budget, nil
+}
+
+// SetAuditLog records every write operation to log. A nil log disables auditing.
+func (c *Client) SetAuditLog(log *audit.Log) {
+	c.auditLog = log
+}
+
+// audit records a write operation. Failing to audit is logged but never fails the operation.
+func (c *Client) audit(operation, method, path, target string, workItemID int, err error) {
+	entry := audit.Entry{
+		Service:    "github",
+		Operation:  operation,
+		Endpoint:   method + " " + path,
+		Target:     target,
+		WorkItemID: workItemID,
+	}
+
+	if auditErr := c.auditLog.Record(entry, err); auditErr != nil {
+		c.logger.Warn("Failed to write audit log entry", "operation", operation, "error", auditErr)
+	}
+}
+
+func (c *Client) repoPath() string {
+	owner, repo := c.repository()
+	return fmt.Sprintf("/repos/%s/%s", owner, repo)
+}
+
+func (c *Client) repoTarget() string {
+	owner, repo := c.repository()
+	return owner + "/" + repo
+}
+
+func (c *Client) issueTarget(issueNumber int) string {
+	return fmt.Sprintf("%s#%d", c.repoTarget(), issueNumber)
+}
+
 func (c *Client) TestConnection(ctx context.Context) error {
 	c.logger.Info("Testing GitHub connection...")
 
 	// Try to get repository information to test the connection
-	_, _, err := c.client.Repositories.Get(ctx, c.config.Owner, c.config.Repository)
+	owner, repo := c.repository()
+	_, _, err := c.client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
 		return fmt.Errorf("connection test failed: %w", err)
 	}
@@ -103,10 +190,17 @@ func (c *Client) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*m
 		githubIssue.Milestone = issue.Milestone
 	}
 
-	createdIssue, _, err := c.client.Issues.Create(ctx, c.config.Owner, c.config.Repository, githubIssue)
+	var createdIssue *github.Issue
+	err := c.withRepository(ctx, func(owner, repo string) error {
+		var err error
+		createdIssue, _, err = c.client.Issues.Create(ctx, owner, repo, githubIssue)
+		return err
+	})
 	if err != nil {
+		c.audit("create_issue", http.MethodPost, c.repoPath()+"/issues", c.repoTarget(), issue.SourceWIID, err)
 		return nil, fmt.Errorf("failed to create issue: %w", err)
 	}
+	c.audit("create_issue", http.MethodPost, c.repoPath()+"/issues", c.issueTarget(createdIssue.GetNumber()), issue.SourceWIID, nil)
 
 	result := &models.GitHubIssue{
 		Number:     createdIssue.GetNumber(),
@@ -135,7 +229,30 @@ func (c *Client) CreateIssueComment(ctx context.Context, issueNumber int, commen
 		Body: &comment.Body,
 	}
 
-	_, _, err := c.client.Issues.CreateComment(ctx, c.config.Owner, c.config.Repository, issueNumber, githubComment)
+	// Comment bursts on busy items trip the secondary rate limit. The issue already exists,
+	// so wait it out instead of failing the item half migrated.
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = c.withRepository(ctx, func(owner, repo string) error {
+			_, _, err := c.client.Issues.CreateComment(ctx, owner, repo, issueNumber, githubComment)
+			return err
+		})
+
+		wait, limited := secondaryLimitWait(err)
+		if !limited || attempt > maxSecondaryLimitRetries {
+			break
+		}
+
+		c.logger.Warn("GitHub secondary rate limit hit while commenting, waiting",
+			"issue", issueNumber,
+			"retry_after", wait,
+			"attempt", attempt)
+		if waitErr := c.waitForRateLimit(ctx, wait); waitErr != nil {
+			err = waitErr
+			break
+		}
+	}
+	c.audit("create_comment", http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", c.repoPath(), issueNumber), c.issueTarget(issueNumber), 0, err)
 	if err != nil {
 		return fmt.Errorf("failed to create comment on issue #%d: %w", issueNumber, err)
 	}
@@ -150,7 +267,11 @@ func (c *Client) UpdateIssueState(ctx context.Context, issueNumber int, state st
 		State: &state,
 	}
 
-	_, _, err := c.client.Issues.Edit(ctx, c.config.Owner, c.config.Repository, issueNumber, issueRequest)
+	err := c.withRepository(ctx, func(owner, repo string) error {
+		_, _, err := c.client.Issues.Edit(ctx, owner, repo, issueNumber, issueRequest)
+		return err
+	})
+	c.audit("update_issue_state", http.MethodPatch, fmt.Sprintf("%s/issues/%d", c.repoPath(), issueNumber), c.issueTarget(issueNumber), 0, err)
 	if err != nil {
 		return fmt.Errorf("failed to update issue #%d state: %w", issueNumber, err)
 	}
@@ -162,7 +283,8 @@ func (c *Client) CreateLabel(ctx context.Context, name, color, description strin
 	c.logger.Debug("Creating/ensuring label", "label", name)
 
 	// Check if label already exists
-	_, resp, err := c.client.Issues.GetLabel(ctx, c.config.Owner, c.config.Repository, name)
+	owner, repo := c.repository()
+	_, resp, err := c.client.Issues.GetLabel(ctx, owner, repo, name)
 	if err == nil {
 		// Label already exists
 		return nil
@@ -179,7 +301,11 @@ func (c *Client) CreateLabel(ctx context.Context, name, color, description strin
 		Description: &description,
 	}
 
-	_, _, err = c.client.Issues.CreateLabel(ctx, c.config.Owner, c.config.Repository, label)
+	err = c.withRepository(ctx, func(owner, repo string) error {
+		_, _, err := c.client.Issues.CreateLabel(ctx, owner, repo, label)
+		return err
+	})
+	c.audit("create_label", http.MethodPost, c.repoPath()+"/labels", fmt.Sprintf("%s label %q", c.repoTarget(), name), 0, err)
 	if err != nil {
 		return fmt.Errorf("failed to create label %s: %w", name, err)
 	}
@@ -190,9 +316,14 @@ func (c *Client) CreateLabel(ctx context.Context, name, color, description strin
 
 func (c *Client) SearchIssues(ctx context.Context, workItemID int) ([]*github.Issue, error) {
 	// Search for issues that contain the work item ID in the body
-	query := fmt.Sprintf("repo:%s/%s \"#%d\" in:body is:issue", c.config.Owner, c.config.Repository, workItemID)
-
-	searchResult, _, err := c.client.Search.Issues(ctx, query, nil)
+	var searchResult *github.IssuesSearchResult
+	err := c.withRepository(ctx, func(owner, repo string) error {
+		query := fmt.Sprintf("repo:%s/%s \"#%d\" in:body is:issue", owner, repo, workItemID)
+
+		var err error
+		searchResult, _, err = c.client.Search.Issues(ctx, query, nil)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search for existing issues: %w", err)
 	}
@@ -200,11 +331,61 @@ func (c *Client) SearchIssues(ctx context.Context, workItemID int) ([]*github.Is
 	return searchResult.Issues, nil
 }
 
+// SearchIssuesByTitle returns issues whose title contains the words of title.
+// Search matches loosely, so callers should compare the returned titles.
+func (c *Client) SearchIssuesByTitle(ctx context.Context, title string) ([]*github.Issue, error) {
+	var searchResult *github.IssuesSearchResult
+	err := c.withRepository(ctx, func(owner, repo string) error {
+		query := fmt.Sprintf("repo:%s/%s \"%s\" in:title is:issue", owner, repo, strings.ReplaceAll(title, "\"", ""))
+
+		var err error
+		searchResult, _, err = c.client.Search.Issues(ctx, query, nil)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search issues by title: %w", err)
+	}
+
+	return searchResult.Issues, nil
+}
+
+// IsAssignable reports whether login can be assigned issues in the repository. It only
+// reads, so dry runs use it to find assignees a live run would have rejected. Results are cached.
+func (c *Client) IsAssignable(ctx context.Context, login string) (bool, error) {
+	key := strings.ToLower(login)
+
+	c.assignableMu.Lock()
+	assignable, cached := c.assignable[key]
+	c.assignableMu.Unlock()
+	if cached {
+		return assignable, nil
+	}
+
+	err := c.withRepository(ctx, func(owner, repo string) error {
+		var err error
+		assignable, _, err = c.client.Issues.IsAssignee(ctx, owner, repo, login)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check assignee %s: %w", login, err)
+	}
+
+	c.assignableMu.Lock()
+	if c.assignable == nil {
+		c.assignable = make(map[string]bool)
+	}
+	c.assignable[key] = assignable
+	c.assignableMu.Unlock()
+
+	return assignable, nil
+}
+
 func (c *Client) ValidateLabels(ctx context.Context, labels []string) error {
 	c.logger.Debug("Validating labels in repository")
 
 	for _, label := range labels {
-		_, resp, err := c.client.Issues.GetLabel(ctx, c.config.Owner, c.config.Repository, label)
+		owner, repo := c.repository()
+		_, resp, err := c.client.Issues.GetLabel(ctx, owner, repo, label)
 		if err != nil && resp.StatusCode == http.StatusNotFound {
 			// Label doesn't exist, create it with a default color
 			if err := c.CreateLabel(ctx, label, "e1e4e8", fmt.Sprintf("Label for %s", label)); err != nil {
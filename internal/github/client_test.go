@@ -0,0 +1,35 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAssignable(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/api/v3/repos/old/repo/assignees/octocat" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	assignable, err := client.IsAssignable(context.Background(), "octocat")
+	require.NoError(t, err)
+	assert.True(t, assignable)
+
+	assignable, err = client.IsAssignable(context.Background(), "ghost")
+	require.NoError(t, err)
+	assert.False(t, assignable)
+
+	// Answers are cached, case-insensitively like GitHub logins
+	_, err = client.IsAssignable(context.Background(), "OctoCat")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
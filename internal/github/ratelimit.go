@@ -0,0 +1,53 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// defaultSecondaryLimitWait is used when a secondary rate limit response has no Retry-After.
+// GitHub asks to wait at least a minute in that case.
+const defaultSecondaryLimitWait = time.Minute
+
+// maxSecondaryLimitRetries bounds how often a single request waits out a secondary rate limit
+const maxSecondaryLimitRetries = 3
+
+// SetRateLimitObserver calls observe with every wait spent on a rate limit. A nil observer disables it.
+func (c *Client) SetRateLimitObserver(observe func(time.Duration)) {
+	c.observeWait = observe
+}
+
+// secondaryLimitWait returns how long to wait before retrying when err is a secondary rate limit
+func secondaryLimitWait(err error) (time.Duration, bool) {
+	var abuseErr *github.AbuseRateLimitError
+	if !errors.As(err, &abuseErr) {
+		return 0, false
+	}
+
+	if abuseErr.RetryAfter != nil && *abuseErr.RetryAfter >= 0 {
+		return *abuseErr.RetryAfter, true
+	}
+
+	return defaultSecondaryLimitWait, true
+}
+
+// waitForRateLimit sleeps for wait unless ctx ends first
+func (c *Client) waitForRateLimit(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if c.observeWait != nil {
+		c.observeWait(wait)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// secondaryLimited responds to the first n comment requests with a secondary rate limit
+func secondaryLimited(n int, calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= n {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"You have exceeded a secondary rate limit","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}
+}
+
+func TestCreateIssueCommentSecondaryLimit(t *testing.T) {
+	t.Run("waits and continues", func(t *testing.T) {
+		calls := 0
+		client := newTestClient(t, secondaryLimited(2, &calls))
+		waits := 0
+		client.SetRateLimitObserver(func(time.Duration) { waits++ })
+
+		err := client.CreateIssueComment(context.Background(), 1, &models.GitHubComment{Body: "Comment"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 2, waits)
+	})
+
+	t.Run("gives up after the retry limit", func(t *testing.T) {
+		calls := 0
+		client := newTestClient(t, secondaryLimited(10, &calls))
+
+		err := client.CreateIssueComment(context.Background(), 1, &models.GitHubComment{Body: "Comment"})
+		assert.Error(t, err)
+		assert.Equal(t, maxSecondaryLimitRetries+1, calls)
+	})
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// ErrRepositoryUnavailable is returned when the target repository was archived, deleted or
+// moved somewhere that can't be followed. The run should stop and continue from the
+// checkpoint once github.owner and github.repository are updated.
+var ErrRepositoryUnavailable = errors.New("target repository is unavailable")
+
+// maxRedirects bounds the redirects followed for a single read
+const maxRedirects = 10
+
+// checkRedirect follows redirects for reads only. Go replays a redirected POST as a GET,
+// so writes get the redirect back and the rename is resolved by resolveRepository.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if method := via[0].Method; method != http.MethodGet && method != http.MethodHead {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	return nil
+}
+
+// repository returns the owner and name of the target repository. They change when
+// a rename is followed mid-run.
+func (c *Client) repository() (string, string) {
+	c.repoMu.RLock()
+	defer c.repoMu.RUnlock()
+
+	return c.config.Owner, c.config.Repository
+}
+
+// withRepository runs op against the target repository. When op fails because the
+// repository moved, the move is followed and op runs once more.
+func (c *Client) withRepository(ctx context.Context, op func(owner, repo string) error) error {
+	err := op(c.repository())
+	if err == nil {
+		return nil
+	}
+
+	if err := c.resolveRepository(ctx, err); err != nil {
+		return err
+	}
+
+	return op(c.repository())
+}
+
+// resolveRepository looks the target repository up again after err. A rename or transfer
+// is followed and nil returned so the caller can retry. An archived or missing repository
+// returns ErrRepositoryUnavailable with what to fix; any other error is returned unchanged.
+func (c *Client) resolveRepository(ctx context.Context, err error) error {
+	if !isRepositoryError(err) {
+		return err
+	}
+
+	owner, name := c.repository()
+	// Reads follow the rename redirect to the repository's new location
+	repo, resp, getErr := c.client.Repositories.Get(ctx, owner, name)
+	if getErr != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone) {
+			return fmt.Errorf("%w: %s/%s was not found, it was deleted, is no longer accessible to the token or was moved without a redirect; update github.owner and github.repository and rerun with --resume",
+				ErrRepositoryUnavailable, owner, name)
+		}
+		return err
+	}
+
+	if repo.GetArchived() {
+		return fmt.Errorf("%w: %s is archived and read-only; unarchive it or update github.owner and github.repository and rerun with --resume",
+			ErrRepositoryUnavailable, repo.GetFullName())
+	}
+
+	newOwner, newName := repo.GetOwner().GetLogin(), repo.GetName()
+	if newOwner == "" || newName == "" || (strings.EqualFold(newOwner, owner) && strings.EqualFold(newName, name)) {
+		// The repository is where it was, the error is about something else
+		return err
+	}
+
+	c.logger.Warn("Target repository was renamed or transferred, following it. Update github.owner and github.repository in the config",
+		"from", owner+"/"+name,
+		"to", repo.GetFullName())
+
+	c.repoMu.Lock()
+	c.config.Owner = newOwner
+	c.config.Repository = newName
+	c.repoMu.Unlock()
+
+	return nil
+}
+
+// isRepositoryError reports whether err may be caused by the repository being renamed,
+// transferred, archived or deleted
+func isRepositoryError(err error) bool {
+	var redirect *github.RedirectionError
+	if errors.As(err, &redirect) {
+		return true
+	}
+
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+
+	switch errResp.Response.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return true
+	case http.StatusForbidden:
+		return strings.Contains(strings.ToLower(errResp.Message), "archived")
+	case http.StatusUnprocessableEntity:
+		// Searching a repository that no longer exists under the name is a validation error
+		for _, detail := range errResp.Errors {
+			if strings.Contains(detail.Message, "cannot be searched") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// newTestClient returns a client for old/repo that talks to handler as a GitHub Enterprise server
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&config.GitHubConfig{
+		Token:      "token",
+		Owner:      "old",
+		Repository: "repo",
+		BaseURL:    server.URL + "/",
+	}, slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestRepositoryMoves(t *testing.T) {
+	t.Run("renamed repository is followed", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+			switch {
+			case strings.HasPrefix(path, "/repos/old/repo"):
+				w.Header().Set("Location", "/api/v3/repositories/1"+strings.TrimPrefix(path, "/repos/old/repo"))
+				w.WriteHeader(http.StatusMovedPermanently)
+			case r.Method == http.MethodGet && path == "/repositories/1":
+				w.Write([]byte(`{"name":"renamed","full_name":"new/renamed","owner":{"login":"new"}}`))
+			case r.Method == http.MethodPost && path == "/repos/new/renamed/issues":
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"number":5,"title":"Title","state":"open"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+
+		issue, err := client.CreateIssue(context.Background(), &models.GitHubIssue{Title: "Title"})
+		require.NoError(t, err)
+		assert.Equal(t, 5, issue.Number)
+
+		owner, repo := client.repository()
+		assert.Equal(t, "new", owner)
+		assert.Equal(t, "renamed", repo)
+	})
+
+	t.Run("archived repository stops with a precise error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"name":"repo","full_name":"old/repo","owner":{"login":"old"},"archived":true}`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Repository was archived so is read-only."}`))
+		})
+
+		err := client.CreateIssueComment(context.Background(), 1, &models.GitHubComment{Body: "Comment"})
+		assert.ErrorIs(t, err, ErrRepositoryUnavailable)
+		assert.Contains(t, err.Error(), "old/repo is archived")
+	})
+
+	t.Run("deleted repository stops with a precise error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		})
+
+		err := client.UpdateIssueState(context.Background(), 1, "closed")
+		assert.ErrorIs(t, err, ErrRepositoryUnavailable)
+		assert.Contains(t, err.Error(), "old/repo was not found")
+	})
+
+	t.Run("missing issue in an existing repository is not a move", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				w.Write([]byte(`{"name":"repo","full_name":"old/repo","owner":{"login":"old"}}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		})
+
+		err := client.UpdateIssueState(context.Background(), 1, "closed")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrRepositoryUnavailable)
+	})
+}
@@ -0,0 +1,67 @@
+package github
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Request categories tracked by the counting transport. They match GitHub's separate rate limits.
+const (
+	RequestCategoryREST    = "rest"
+	RequestCategoryGraphQL = "graphql"
+	RequestCategorySearch  = "search"
+)
+
+// countingTransport counts the requests sent to GitHub per category
+type countingTransport struct {
+	base   http.RoundTripper
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newCountingTransport(base http.RoundTripper) *countingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &countingTransport{base: base, counts: map[string]int{}}
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Checking the rate limit is free, so it is not counted
+	path := strings.TrimPrefix(req.URL.Path, "/api/v3")
+	if path != "/rate_limit" {
+		t.mu.Lock()
+		t.counts[requestCategory(path)]++
+		t.mu.Unlock()
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// snapshot returns a copy of the counts so far
+func (t *countingTransport) snapshot() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int, len(t.counts))
+	for category, count := range t.counts {
+		counts[category] = count
+	}
+
+	return counts
+}
+
+// requestCategory classifies a request path. Enterprise servers prefix paths with /api/v3,
+// which callers strip first.
+func requestCategory(path string) string {
+	switch {
+	case path == "/graphql" || path == "/api/graphql":
+		return RequestCategoryGraphQL
+	case strings.HasPrefix(path, "/search/"):
+		return RequestCategorySearch
+	default:
+		return RequestCategoryREST
+	}
+}
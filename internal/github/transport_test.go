@@ -0,0 +1,38 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := newCountingTransport(nil)
+	client := &http.Client{Transport: transport}
+
+	for _, path := range []string{
+		"/repos/owner/repo/issues",
+		"/api/v3/repos/owner/repo/issues/1/comments",
+		"/search/issues",
+		"/graphql",
+		"/rate_limit",
+	} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, map[string]int{
+		RequestCategoryREST:    2,
+		RequestCategorySearch:  1,
+		RequestCategoryGraphQL: 1,
+	}, transport.snapshot())
+}
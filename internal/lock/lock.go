@@ -0,0 +1,328 @@
+// Package lock provides a lease based lock file that keeps two migration runs
+// from working against the same checkpoint at the same time.
+//
+// The lease expires after its TTL so a crashed run does not block later runs
+// forever. Long running holders renew it in the background with KeepAlive and
+// must stop once Err reports the lease was lost. Lock files are always written
+// to a temporary file first and then linked or renamed into place, so readers
+// never see a half written lease. Takeovers, renewals and releases read and
+// replace the lease while holding a guard file that only one run can create, so
+// two runs never both replace the same lease.
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned when another holder owns an unexpired lease
+var ErrLocked = errors.New("lock is held by another run")
+
+// ErrInvalidLease is returned by Read when the lock file does not hold a valid lease
+var ErrInvalidLease = errors.New("lock file does not hold a valid lease")
+
+// unknownOwner names the holder of a lock file that can't be parsed
+const unknownOwner = "unknown owner"
+
+const (
+	// guardWait bounds how long a lease change waits for another run's change to finish
+	guardWait  = 2 * time.Second
+	guardRetry = 10 * time.Millisecond
+	// guardStale is the age after which a guard was left by a run that crashed mid-change
+	guardStale = time.Minute
+)
+
+// Lease is the content of a lock file
+type Lease struct {
+	Owner      string    `json:"owner"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Lock is a lease held by the current process
+type Lock struct {
+	path  string
+	ttl   time.Duration
+	lease Lease
+
+	mu  sync.Mutex
+	err error
+}
+
+// DefaultOwner identifies the current process as host:pid
+func DefaultOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Acquire takes the lock at path for ttl. An expired lease left by another
+// owner is taken over; an active one fails with ErrLocked.
+func Acquire(path, owner string, ttl time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	l := &Lock{path: path, ttl: ttl}
+
+	// Retry once when the lease was released between creating and reading it
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		l.lease = Lease{Owner: owner, AcquiredAt: now, ExpiresAt: now.Add(ttl)}
+
+		err := l.create()
+		if err == nil {
+			return l, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		existing, err := l.current()
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			continue
+		}
+		if existing.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: %s holds %s until %s", ErrLocked, existing.Owner, path, existing.ExpiresAt.Format(time.RFC3339))
+		}
+
+		if err := l.takeOver(existing); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+}
+
+// Read returns the lease stored at path, or nil when the file does not exist.
+// A file that can't be parsed fails with ErrInvalidLease.
+func Read(path string) (*Lease, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	lease := &Lease{}
+	if err := json.Unmarshal(data, lease); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidLease, path)
+	}
+
+	return lease, nil
+}
+
+// Renew extends the lease by the TTL. It fails if the lease expired and was
+// taken over by another run, in which case the caller must stop.
+func (l *Lock) Renew() error {
+	return l.withGuard(func() error {
+		current, err := l.current()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Owner != l.lease.Owner {
+			return fmt.Errorf("%w: lease on %s was lost", ErrLocked, l.path)
+		}
+
+		l.lease.ExpiresAt = time.Now().Add(l.ttl)
+		return l.write()
+	})
+}
+
+// KeepAlive renews the lease every third of the TTL in the background until the
+// returned stop function is called. A failed renewal ends the loop and is
+// reported by Err.
+func (l *Lock) KeepAlive() (stop func()) {
+	interval := l.ttl / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := l.Renew(); err != nil {
+					l.mu.Lock()
+					l.err = err
+					l.mu.Unlock()
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// Err returns the error of the last failed background renewal, or nil while the lease is held
+func (l *Lock) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Release removes the lock file if it is still owned by this lock
+func (l *Lock) Release() error {
+	return l.withGuard(func() error {
+		current, err := l.current()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Owner != l.lease.Owner {
+			return nil
+		}
+
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove lock file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// current returns the lease at the lock path. A lock file that can't be parsed
+// may still be written by a run using an older version, so it counts as held
+// until its modification time plus the TTL.
+func (l *Lock) current() (*Lease, error) {
+	lease, err := Read(l.path)
+	if !errors.Is(err, ErrInvalidLease) {
+		return lease, err
+	}
+
+	info, err := os.Stat(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat lock file: %w", err)
+	}
+
+	return &Lease{Owner: unknownOwner, AcquiredAt: info.ModTime(), ExpiresAt: info.ModTime().Add(l.ttl)}, nil
+}
+
+// takeOver replaces the expired lease with ours. Another run may be taking over
+// the same lease, so the file is only replaced while it still holds the expired
+// lease, checked and replaced under the guard.
+func (l *Lock) takeOver(expired *Lease) error {
+	return l.withGuard(func() error {
+		current, err := l.current()
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Owner != expired.Owner || !current.ExpiresAt.Equal(expired.ExpiresAt) {
+			return fmt.Errorf("%w: %s was taken over by another run", ErrLocked, l.path)
+		}
+
+		return l.write()
+	})
+}
+
+// withGuard runs change while holding the guard file next to the lock file. The guard is
+// created exclusively, so no other run reads and replaces the lease until change returns.
+// A guard older than guardStale was left by a crashed run and is removed.
+func (l *Lock) withGuard(change func() error) error {
+	guard := l.path + ".guard"
+	deadline := time.Now().Add(guardWait)
+	for {
+		file, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create lock guard: %w", err)
+		}
+
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > guardStale {
+			os.Remove(guard)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s is being changed by another run", ErrLocked, l.path)
+		}
+		time.Sleep(guardRetry)
+	}
+	defer os.Remove(guard)
+
+	return change()
+}
+
+// create links a fully written lease into place, failing with os.ErrExist when the lock file exists
+func (l *Lock) create() error {
+	temp, err := l.writeTemp()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp)
+
+	return os.Link(temp, l.path)
+}
+
+// write replaces the lock file with the current lease
+func (l *Lock) write() error {
+	temp, err := l.writeTemp()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp, l.path); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	return nil
+}
+
+// writeTemp writes the lease to a temporary file next to the lock file and returns its path
+func (l *Lock) writeTemp() (string, error) {
+	data, err := json.Marshal(l.lease)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary lock file: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write temporary lock file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write temporary lock file: %w", err)
+	}
+
+	return file.Name(), nil
+}
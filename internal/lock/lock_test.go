@@ -0,0 +1,186 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Run("second owner is rejected while the lease is active", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.lock")
+
+		first, err := Acquire(path, "host-a:1", time.Minute)
+		require.NoError(t, err)
+
+		_, err = Acquire(path, "host-b:2", time.Minute)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.Contains(t, err.Error(), "host-a:1")
+
+		require.NoError(t, first.Release())
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+
+		second, err := Acquire(path, "host-b:2", time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, second.Release())
+	})
+
+	t.Run("expired lease is taken over", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.lock")
+
+		stale, err := Acquire(path, "host-a:1", -time.Minute)
+		require.NoError(t, err)
+
+		current, err := Acquire(path, "host-b:2", time.Minute)
+		require.NoError(t, err)
+
+		lease, err := Read(path)
+		require.NoError(t, err)
+		assert.Equal(t, "host-b:2", lease.Owner)
+
+		// The previous holder lost the lease and must not remove the new one
+		assert.ErrorIs(t, stale.Renew(), ErrLocked)
+		require.NoError(t, stale.Release())
+		_, err = os.Stat(path)
+		assert.NoError(t, err)
+
+		require.NoError(t, current.Release())
+	})
+}
+
+func TestRenew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.lock")
+
+	l, err := Acquire(path, "host-a:1", time.Minute)
+	require.NoError(t, err)
+	before, err := Read(path)
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, l.Renew())
+
+	after, err := Read(path)
+	require.NoError(t, err)
+	assert.True(t, after.ExpiresAt.After(before.ExpiresAt))
+	assert.Equal(t, before.AcquiredAt.Unix(), after.AcquiredAt.Unix())
+}
+
+func TestStaleGuard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.lock")
+
+	l, err := Acquire(path, "host-a:1", time.Minute)
+	require.NoError(t, err)
+
+	// A run that crashed while changing the lease left its guard behind
+	guard := path + ".guard"
+	require.NoError(t, os.WriteFile(guard, nil, 0600))
+	old := time.Now().Add(-2 * guardStale)
+	require.NoError(t, os.Chtimes(guard, old, old))
+
+	require.NoError(t, l.Renew())
+	_, err = os.Stat(guard)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnparseableLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.lock")
+	require.NoError(t, os.WriteFile(path, []byte(`{"owner": "host-a`), 0600))
+
+	_, err := Read(path)
+	assert.ErrorIs(t, err, ErrInvalidLease)
+
+	// A lease that may still be being written counts as held until its mtime plus the TTL
+	_, err = Acquire(path, "host-b:2", time.Minute)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	old := time.Now().Add(-2 * time.Minute)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	l, err := Acquire(path, "host-b:2", time.Minute)
+	require.NoError(t, err)
+
+	lease, err := Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, "host-b:2", lease.Owner)
+	require.NoError(t, l.Release())
+}
+
+func TestConcurrentTakeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.lock")
+
+	_, err := Acquire(path, "stale:0", -time.Minute)
+	require.NoError(t, err)
+
+	const runs = 8
+	var acquired atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l, err := Acquire(path, fmt.Sprintf("host-%d:%d", i, i), time.Minute)
+			if err != nil {
+				assert.ErrorIs(t, err, ErrLocked)
+				return
+			}
+			acquired.Add(1)
+			assert.NoError(t, l.Renew(), "the run that acquired the lock keeps the lease")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), acquired.Load(), "exactly one run takes over the expired lease")
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary lease and guard files are cleaned up")
+}
+
+func TestKeepAlive(t *testing.T) {
+	t.Run("renews in the background", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.lock")
+
+		l, err := Acquire(path, "host-a:1", 150*time.Millisecond)
+		require.NoError(t, err)
+		before, err := Read(path)
+		require.NoError(t, err)
+
+		stop := l.KeepAlive()
+		time.Sleep(200 * time.Millisecond)
+		stop()
+
+		after, err := Read(path)
+		require.NoError(t, err)
+		assert.True(t, after.ExpiresAt.After(before.ExpiresAt))
+		assert.NoError(t, l.Err())
+		require.NoError(t, l.Release())
+	})
+
+	t.Run("reports a lost lease", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.lock")
+
+		l, err := Acquire(path, "host-a:1", 150*time.Millisecond)
+		require.NoError(t, err)
+
+		stop := l.KeepAlive()
+		defer stop()
+
+		data, err := json.Marshal(Lease{Owner: "host-b:2", ExpiresAt: time.Now().Add(time.Minute)})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+
+		assert.Eventually(t, func() bool {
+			return errors.Is(l.Err(), ErrLocked)
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,68 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/jlucaspains/adowi2gh/internal/github"
+)
+
+// Requests a work item is assumed to need before the run has measured its own usage:
+// the duplicate search plus creating and closing the issue
+const (
+	estimatedRESTPerItem   = 2
+	estimatedSearchPerItem = 1
+)
+
+// requestUsage is the GitHub request count and the number of attempted work items when
+// the migration phase started. Measuring from there keeps the connection tests and the
+// work items skipped as already migrated out of the per item average.
+type requestUsage struct {
+	counts    map[string]int
+	attempted int
+}
+
+// startRequestUsage snapshots the current request usage
+func (e *Engine) startRequestUsage() requestUsage {
+	return requestUsage{counts: e.githubClient.RequestCounts(), attempted: e.results.attemptedItems()}
+}
+
+// warnOnRequestBudget warns when the next batch is projected to use more requests than
+// the hourly rate limit has left. The projection uses the per item average measured so far.
+func (e *Engine) warnOnRequestBudget(ctx context.Context, batchSize int, start requestUsage) {
+	budget, err := e.githubClient.RemainingBudget(ctx)
+	if err != nil {
+		e.logger.Debug("Could not check GitHub rate limit budget", "error", err)
+		return
+	}
+
+	restPerItem, searchPerItem := e.requestsPerItem(start)
+
+	projectedREST := int(restPerItem*float64(batchSize) + 0.5)
+	if projectedREST > budget.Core {
+		e.logger.Warn("Next batch may exceed the remaining GitHub REST budget",
+			"projected", projectedREST,
+			"remaining", budget.Core)
+	}
+
+	projectedSearch := int(searchPerItem*float64(batchSize) + 0.5)
+	if projectedSearch > budget.Search {
+		e.logger.Warn("Next batch may exceed the remaining GitHub search budget",
+			"projected", projectedSearch,
+			"remaining", budget.Search)
+	}
+}
+
+// requestsPerItem averages the REST and search requests of the work items attempted since
+// start. Until one was attempted the estimates are used.
+func (e *Engine) requestsPerItem(start requestUsage) (float64, float64) {
+	attempted := e.results.attemptedItems() - start.attempted
+	if attempted <= 0 {
+		return estimatedRESTPerItem, estimatedSearchPerItem
+	}
+
+	counts := e.githubClient.RequestCounts()
+	rest := counts[github.RequestCategoryREST] - start.counts[github.RequestCategoryREST]
+	search := counts[github.RequestCategorySearch] - start.counts[github.RequestCategorySearch]
+
+	return float64(rest) / float64(attempted), float64(search) / float64(attempted)
+}
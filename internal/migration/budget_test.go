@@ -0,0 +1,44 @@
+package migration
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+func TestRequestsPerItem(t *testing.T) {
+	ctx := context.Background()
+	tracker := newFakeTracker()
+	engine := NewEngine(&fakeSource{}, tracker, nil, &config.MigrationConfig{}, slog.New(slog.DiscardHandler))
+
+	// Requests sent while testing connections don't belong to any work item
+	for range 3 {
+		_, _ = tracker.IsAssignable(ctx, "ana-lima")
+	}
+	engine.checkpoint.ProcessedItems = []int{1, 2, 3}
+
+	start := engine.startRequestUsage()
+	rest, search := engine.requestsPerItem(start)
+	assert.Equal(t, float64(estimatedRESTPerItem), rest)
+	assert.Equal(t, float64(estimatedSearchPerItem), search)
+
+	// Items skipped as already migrated send no requests and are left out of the average
+	for id := 1; id <= 3; id++ {
+		assert.True(t, engine.results.alreadyProcessed(id))
+	}
+	for id := 4; id <= 5; id++ {
+		_, _ = tracker.SearchIssues(ctx, id)
+		issue, _ := tracker.CreateIssue(ctx, &models.GitHubIssue{Title: "Item", SourceWIID: id})
+		_ = tracker.UpdateIssueState(ctx, issue.Number, "closed")
+		engine.results.success(&models.WorkItem{ID: id}, issue, issue.Number)
+	}
+
+	rest, search = engine.requestsPerItem(start)
+	assert.Equal(t, 2.0, rest)
+	assert.Equal(t, 1.0, search)
+}
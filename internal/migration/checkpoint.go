@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// CheckpointSchemaVersion is the checkpoint layout written by this version. Bump it and
+// add a step to checkpointUpgrades whenever a change needs existing checkpoints rewritten.
+const CheckpointSchemaVersion = 2
+
+// ErrUnsupportedCheckpoint is returned for checkpoints written with a newer schema than this tool understands
+var ErrUnsupportedCheckpoint = errors.New("unsupported checkpoint schema version")
+
+// checkpointUpgrades[n] moves a checkpoint from schema n to n+1, so a migration
+// started with an older version can be resumed after upgrading.
+var checkpointUpgrades = []func(*MigrationCheckpoint){
+	upgradeUnversionedCheckpoint,
+	upgradeAttemptedStats,
+}
+
+// LoadCheckpoint reads the checkpoint at path, upgrading older schemas to the current one
+func LoadCheckpoint(path string) (*MigrationCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+
+	checkpoint := &MigrationCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+
+	if err := upgradeCheckpoint(checkpoint); err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// upgradeCheckpoint applies the upgrade steps between the checkpoint's schema and the current one
+func upgradeCheckpoint(checkpoint *MigrationCheckpoint) error {
+	checkpoint.loadedVersion = checkpoint.SchemaVersion
+
+	if checkpoint.SchemaVersion > CheckpointSchemaVersion {
+		return fmt.Errorf("%w: checkpoint uses schema %d, this version supports up to %d; upgrade adowi2gh",
+			ErrUnsupportedCheckpoint, checkpoint.SchemaVersion, CheckpointSchemaVersion)
+	}
+
+	for checkpoint.SchemaVersion < CheckpointSchemaVersion {
+		checkpointUpgrades[checkpoint.SchemaVersion](checkpoint)
+		checkpoint.SchemaVersion++
+	}
+
+	return nil
+}
+
+// upgradeUnversionedCheckpoint upgrades checkpoints written before schema versioning
+func upgradeUnversionedCheckpoint(checkpoint *MigrationCheckpoint) {
+	// Mappings were not always kept ordered
+	sort.SliceStable(checkpoint.Mappings, func(i, j int) bool {
+		return checkpoint.Mappings[i].AdoWorkItemID < checkpoint.Mappings[j].AdoWorkItemID
+	})
+
+	// Failures recorded before the retry queue existed would otherwise never be retried
+	if len(checkpoint.RetryQueue) == 0 {
+		for _, id := range checkpoint.FailedItems {
+			if !slices.Contains(checkpoint.ProcessedItems, id) {
+				checkpoint.RetryQueue = append(checkpoint.RetryQueue, RetryEntry{WorkItemID: id, Attempts: 1})
+			}
+		}
+	}
+
+	// Statistics were not recorded, count what the item lists tell
+	if checkpoint.Stats.Processed() == 0 {
+		checkpoint.Stats.Successful = len(checkpoint.ProcessedItems)
+		checkpoint.Stats.Failed = len(checkpoint.FailedItems)
+	}
+}
+
+// upgradeAttemptedStats fills in the statistics schema 1 checkpoints lack
+func upgradeAttemptedStats(checkpoint *MigrationCheckpoint) {
+	// Skips are left out, those of earlier runs can't be told apart from items skipped as migrated
+	checkpoint.Stats.Attempted = checkpoint.Stats.Successful + checkpoint.Stats.Failed
+	// The total held the scope of the latest run, a retry run may have shrunk it
+	checkpoint.Stats.RunWorkItems = checkpoint.Stats.TotalWorkItems
+}
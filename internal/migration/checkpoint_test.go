@@ -0,0 +1,98 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCheckpoint(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadCheckpoint_UpgradesUnversioned(t *testing.T) {
+	path := writeCheckpoint(t, `{
+  "last_processed_id": 5,
+  "processed_items": [1, 5],
+  "failed_items": [3, 5],
+  "mappings": [
+    {"ado_work_item_id": 5, "status": "success"},
+    {"ado_work_item_id": 1, "status": "success"},
+    {"ado_work_item_id": 3, "status": "failed"}
+  ]
+}`)
+
+	checkpoint, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, CheckpointSchemaVersion, checkpoint.SchemaVersion)
+	assert.Equal(t, 0, checkpoint.loadedVersion)
+	require.Len(t, checkpoint.Mappings, 3)
+	assert.Equal(t, []int{1, 3, 5}, []int{
+		checkpoint.Mappings[0].AdoWorkItemID,
+		checkpoint.Mappings[1].AdoWorkItemID,
+		checkpoint.Mappings[2].AdoWorkItemID,
+	})
+	// Item 5 failed once but was migrated later, so only item 3 is queued for retry
+	assert.Equal(t, []RetryEntry{{WorkItemID: 3, Attempts: 1}}, checkpoint.RetryQueue)
+	assert.Equal(t, 2, checkpoint.Stats.Successful)
+	assert.Equal(t, 2, checkpoint.Stats.Failed)
+}
+
+func TestLoadCheckpoint_UpgradesAttemptedStats(t *testing.T) {
+	path := writeCheckpoint(t, `{
+  "schema_version": 1,
+  "processed_items": [1],
+  "failed_items": [3],
+  "stats": {"total_work_items": 3, "successful": 1, "failed": 4, "skipped": 20, "item_duration_ns": 5000000000}
+}`)
+
+	checkpoint, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+
+	assert.Empty(t, checkpoint.RetryQueue)
+	assert.Equal(t, 5, checkpoint.Stats.Attempted, "skips are left out of the attempted items")
+	assert.Equal(t, time.Second, checkpoint.Stats.AverageItemDuration())
+	assert.Equal(t, 3, checkpoint.Stats.RunWorkItems)
+}
+
+func TestLoadCheckpoint_CurrentVersionUnchanged(t *testing.T) {
+	path := writeCheckpoint(t, `{
+  "schema_version": 2,
+  "processed_items": [1],
+  "failed_items": [3],
+  "stats": {"total_work_items": 10, "run_work_items": 3, "successful": 1, "failed": 4, "attempted": 2}
+}`)
+
+	checkpoint, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+
+	assert.Empty(t, checkpoint.RetryQueue)
+	assert.Equal(t, 4, checkpoint.Stats.Failed)
+	assert.Equal(t, 2, checkpoint.Stats.Attempted)
+	assert.Equal(t, 10, checkpoint.Stats.TotalWorkItems)
+	assert.Equal(t, 3, checkpoint.Stats.RunWorkItems)
+}
+
+func TestLoadCheckpoint_RejectsNewerSchema(t *testing.T) {
+	path := writeCheckpoint(t, `{"schema_version": 99}`)
+
+	_, err := LoadCheckpoint(path)
+	assert.ErrorIs(t, err, ErrUnsupportedCheckpoint)
+}
+
+func TestCheckpointJSON_WritesSchemaVersion(t *testing.T) {
+	checkpoint := &MigrationCheckpoint{}
+	results := newCollector(nil, checkpoint, 3)
+
+	data, err := results.checkpointJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"schema_version": 2`)
+}
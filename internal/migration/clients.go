@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	gh "github.com/google/go-github/v74/github"
+
+	"github.com/jlucaspains/adowi2gh/internal/github"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// WorkItemSource is the Azure DevOps side of a migration, implemented by ado.Client
+type WorkItemSource interface {
+	TestConnection(ctx context.Context) error
+	SetFieldProjection(fields []string)
+	QueryWorkItemIDs(ctx context.Context) ([]int, error)
+	StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error
+	GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error)
+	SetRateLimitObserver(observe func(time.Duration))
+}
+
+// IssueTracker is the GitHub side of a migration, implemented by github.Client
+type IssueTracker interface {
+	TestConnection(ctx context.Context) error
+	CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error)
+	CreateIssueComment(ctx context.Context, issueNumber int, comment *models.GitHubComment) error
+	UpdateIssueState(ctx context.Context, issueNumber int, state string) error
+	SearchIssues(ctx context.Context, workItemID int) ([]*gh.Issue, error)
+	SearchIssuesByTitle(ctx context.Context, title string) ([]*gh.Issue, error)
+	IsAssignable(ctx context.Context, login string) (bool, error)
+	ValidateLabels(ctx context.Context, labels []string) error
+	RequestCounts() map[string]int
+	RemainingBudget(ctx context.Context) (*github.RateLimitBudget, error)
+	SetRateLimitObserver(observe func(time.Duration))
+}
@@ -0,0 +1,207 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// collector accumulates the outcome of every work item into the report and the
+// checkpoint. Workers record concurrently, so all access goes through the mutex.
+// Mappings are appended as recorded and put in work item ID order when the report or the
+// checkpoint is written, so the output doesn't depend on scheduling.
+type collector struct {
+	mu               sync.Mutex
+	report           *models.MigrationReport
+	checkpoint       *MigrationCheckpoint
+	maxRetryAttempts int
+	includeTrace     bool // Copy the mapping trace of each issue into its mapping
+	attempted        int  // Work items this run worked on, leaving out those skipped as already migrated
+}
+
+func newCollector(report *models.MigrationReport, checkpoint *MigrationCheckpoint, maxRetryAttempts int) *collector {
+	return &collector{
+		report:           report,
+		checkpoint:       checkpoint,
+		maxRetryAttempts: maxRetryAttempts,
+	}
+}
+
+// success records a migrated work item and removes it from the retry queue
+func (c *collector) success(workItem *models.WorkItem, issue *models.GitHubIssue, issueNumber int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempted++
+	c.checkpoint.Stats.Attempted++
+	c.report.SuccessfulCount++
+	c.checkpoint.Stats.Successful++
+	c.checkpoint.ProcessedItems = append(c.checkpoint.ProcessedItems, workItem.ID)
+	c.checkpoint.clearRetry(workItem.ID)
+	c.checkpoint.LastProcessedID = max(c.checkpoint.LastProcessedID, workItem.ID)
+	c.checkpoint.LastUpdate = time.Now()
+	c.addMapping(workItem, issue, issueNumber, "success", "", "")
+}
+
+// failure records a failed work item and queues it for retry. The retry entry is returned
+// so the caller can tell whether the item was escalated.
+func (c *collector) failure(workItem *models.WorkItem, err error) RetryEntry {
+	category := categorizeError(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempted++
+	c.checkpoint.Stats.Attempted++
+	c.report.FailedCount++
+	c.checkpoint.Stats.Failed++
+	if !slices.Contains(c.checkpoint.FailedItems, workItem.ID) {
+		c.checkpoint.FailedItems = append(c.checkpoint.FailedItems, workItem.ID)
+	}
+	c.report.Errors = append(c.report.Errors, workItemError(workItem, err.Error()))
+	c.addMapping(workItem, nil, 0, "failed", err.Error(), category)
+
+	// Copy the entry, the queue may grow once the mutex is released
+	return *c.checkpoint.recordRetry(workItem.ID, category, err.Error(), c.maxRetryAttempts)
+}
+
+// outcome counts a work item under status and records its mapping without touching the
+// retry queue. Dry runs and skipped items use it.
+func (c *collector) outcome(workItem *models.WorkItem, issue *models.GitHubIssue, issueNumber int, status, errorMsg, errorCategory string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if status != "excluded" {
+		c.attempted++
+		c.checkpoint.Stats.Attempted++
+	}
+	switch status {
+	case "success":
+		c.report.SuccessfulCount++
+		c.checkpoint.Stats.Successful++
+	case "failed":
+		c.report.FailedCount++
+		c.checkpoint.Stats.Failed++
+	case "skipped":
+		c.report.SkippedCount++
+		c.checkpoint.Stats.Skipped++
+	case "excluded":
+		c.report.ExcludedCount++
+	}
+	c.addMapping(workItem, issue, issueNumber, status, errorMsg, errorCategory)
+}
+
+// alreadyProcessed counts a work item skipped because the checkpoint has it as migrated
+func (c *collector) alreadyProcessed(workItemID int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !slices.Contains(c.checkpoint.ProcessedItems, workItemID) {
+		return false
+	}
+	c.report.SkippedCount++
+	c.checkpoint.Stats.Skipped++
+
+	return true
+}
+
+// attemptedItems returns how many work items this run worked on
+func (c *collector) attemptedItems() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.attempted
+}
+
+// addMapping records a mapping. issue is nil when the item was not mapped. Callers hold the mutex.
+func (c *collector) addMapping(workItem *models.WorkItem, issue *models.GitHubIssue, issueNumber int, status, errorMsg, errorCategory string) {
+	mapping := models.MigrationMapping{
+		AdoWorkItemID:   workItem.ID,
+		AdoWorkItemType: workItem.GetWorkItemType(),
+		AdoWorkItemURL:  workItem.WebURL(),
+		GitHubIssueID:   issueNumber,
+		MigratedAt:      time.Now(),
+		Status:          status,
+		ErrorMessage:    errorMsg,
+		ErrorCategory:   errorCategory,
+	}
+	if issue != nil {
+		mapping.TargetState = issue.State
+		mapping.Labels = issue.Labels
+		if c.includeTrace {
+			mapping.Trace, _ = issue.Metadata["mapping_trace"].([]string)
+		}
+		mapping.RejectedAssignees, _ = issue.Metadata["rejected_assignees"].([]string)
+	}
+
+	c.report.Mappings = append(c.report.Mappings, mapping)
+	c.checkpoint.Mappings = append(c.checkpoint.Mappings, mapping)
+}
+
+// workItemError prefixes message with the work item ID and, when known, its ADO page so the
+// offending item is one click away
+func workItemError(workItem *models.WorkItem, message string) string {
+	if url := workItem.WebURL(); url != "" {
+		return fmt.Sprintf("Work Item %d (%s): %s", workItem.ID, url, message)
+	}
+
+	return fmt.Sprintf("Work Item %d: %s", workItem.ID, message)
+}
+
+// checkpointJSON snapshots the checkpoint so it can be written while workers keep recording
+func (c *collector) checkpointJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.Ints(c.checkpoint.ProcessedItems)
+	sort.Ints(c.checkpoint.FailedItems)
+	c.checkpoint.Mappings = sortMappings(c.checkpoint.Mappings)
+	c.checkpoint.SchemaVersion = CheckpointSchemaVersion
+
+	return json.MarshalIndent(c.checkpoint, "", "  ")
+}
+
+// sortReport puts the report mappings in work item ID order
+func (c *collector) sortReport() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.report.Mappings = sortMappings(c.report.Mappings)
+}
+
+// sortMappings orders mappings by work item ID. Mappings for the same item (e.g. a failure
+// and a later retry) stay in the order they were recorded. Mappings are sorted every time
+// the checkpoint is saved, so only the ones recorded since are sorted and merged in.
+func sortMappings(mappings []models.MigrationMapping) []models.MigrationMapping {
+	sorted := 1
+	for sorted < len(mappings) && mappings[sorted-1].AdoWorkItemID <= mappings[sorted].AdoWorkItemID {
+		sorted++
+	}
+	if sorted >= len(mappings) {
+		return mappings
+	}
+
+	head, tail := mappings[:sorted], mappings[sorted:]
+	sort.SliceStable(tail, func(i, j int) bool {
+		return tail[i].AdoWorkItemID < tail[j].AdoWorkItemID
+	})
+
+	merged := make([]models.MigrationMapping, 0, len(mappings))
+	for len(head) > 0 && len(tail) > 0 {
+		if tail[0].AdoWorkItemID < head[0].AdoWorkItemID {
+			merged = append(merged, tail[0])
+			tail = tail[1:]
+		} else {
+			merged = append(merged, head[0])
+			head = head[1:]
+		}
+	}
+	merged = append(merged, head...)
+
+	return append(merged, tail...)
+}
@@ -0,0 +1,142 @@
+package migration
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+func TestSortMappings(t *testing.T) {
+	mapping := func(id int, status string) models.MigrationMapping {
+		return models.MigrationMapping{AdoWorkItemID: id, Status: status}
+	}
+
+	tests := []struct {
+		name     string
+		mappings []models.MigrationMapping
+		expected []models.MigrationMapping
+	}{
+		{name: "empty"},
+		{
+			name:     "already sorted",
+			mappings: []models.MigrationMapping{mapping(1, "success"), mapping(2, "success")},
+			expected: []models.MigrationMapping{mapping(1, "success"), mapping(2, "success")},
+		},
+		{
+			name:     "recorded out of order",
+			mappings: []models.MigrationMapping{mapping(3, "success"), mapping(1, "success"), mapping(2, "failed")},
+			expected: []models.MigrationMapping{mapping(1, "success"), mapping(2, "failed"), mapping(3, "success")},
+		},
+		{
+			name: "new mappings merge into a sorted checkpoint",
+			mappings: []models.MigrationMapping{
+				mapping(1, "success"), mapping(4, "failed"), mapping(6, "success"),
+				mapping(5, "success"), mapping(4, "success"), mapping(2, "skipped"),
+			},
+			expected: []models.MigrationMapping{
+				mapping(1, "success"), mapping(2, "skipped"), mapping(4, "failed"),
+				mapping(4, "success"), mapping(5, "success"), mapping(6, "success"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sortMappings(tt.mappings))
+		})
+	}
+}
+
+func TestCollector(t *testing.T) {
+	t.Run("concurrent records are ordered by work item ID", func(t *testing.T) {
+		report := &models.MigrationReport{}
+		checkpoint := &MigrationCheckpoint{}
+		results := newCollector(report, checkpoint, 3)
+
+		var wg sync.WaitGroup
+		for id := 50; id >= 1; id-- {
+			wg.Add(1)
+			go func(id int) {
+				defer wg.Done()
+				workItem := &models.WorkItem{ID: id, Fields: map[string]interface{}{}}
+				switch id % 3 {
+				case 0:
+					results.success(workItem, nil, id+1000)
+				case 1:
+					results.failure(workItem, errors.New("boom"))
+				default:
+					results.outcome(workItem, nil, 0, "skipped", "Issue already exists", "")
+				}
+			}(id)
+		}
+		wg.Wait()
+
+		assert.Equal(t, 16, report.SuccessfulCount)
+		assert.Equal(t, 17, report.FailedCount)
+		assert.Equal(t, 17, report.SkippedCount)
+		require.Len(t, report.Mappings, 50)
+		require.Len(t, checkpoint.Mappings, 50)
+
+		results.sortReport()
+		_, err := results.checkpointJSON()
+		require.NoError(t, err)
+		for i := range 50 {
+			assert.Equal(t, i+1, report.Mappings[i].AdoWorkItemID)
+			assert.Equal(t, i+1, checkpoint.Mappings[i].AdoWorkItemID)
+		}
+		assert.Equal(t, 48, checkpoint.LastProcessedID)
+	})
+
+	t.Run("already processed items are counted as skipped", func(t *testing.T) {
+		report := &models.MigrationReport{}
+		results := newCollector(report, &MigrationCheckpoint{ProcessedItems: []int{7}}, 3)
+
+		assert.True(t, results.alreadyProcessed(7))
+		assert.False(t, results.alreadyProcessed(8))
+		assert.Equal(t, 1, report.SkippedCount)
+	})
+	t.Run("running statistics", func(t *testing.T) {
+		checkpoint := &MigrationCheckpoint{Stats: CheckpointStats{Runs: 1, Successful: 2}}
+		results := newCollector(&models.MigrationReport{}, checkpoint, 3)
+
+		results.startRun(10)
+		results.success(&models.WorkItem{ID: 1, Fields: map[string]interface{}{}}, nil, 101)
+		results.failure(&models.WorkItem{ID: 2, Fields: map[string]interface{}{}}, errors.New("boom"))
+		results.outcome(&models.WorkItem{ID: 3, Fields: map[string]interface{}{}}, nil, 0, "skipped", "", "")
+		assert.True(t, results.alreadyProcessed(1))
+		results.itemDuration(3 * time.Second)
+		results.batchFinished()
+		results.rateLimitWait(2 * time.Second)
+
+		stats := checkpoint.Stats
+		assert.Equal(t, 2, stats.Runs)
+		assert.Equal(t, 10, stats.RunWorkItems)
+		assert.Equal(t, 3, stats.Successful)
+		assert.Equal(t, 1, stats.Failed)
+		assert.Equal(t, 2, stats.Skipped, "items skipped from the checkpoint count too")
+		assert.Equal(t, 3, stats.Attempted, "items skipped from the checkpoint were not worked on")
+		assert.Equal(t, 1, stats.Batches)
+		assert.Equal(t, time.Second, stats.AverageItemDuration())
+		assert.Equal(t, 1, stats.RateLimitWaits)
+		assert.Equal(t, 2*time.Second, stats.RateLimitWait)
+	})
+	t.Run("errors link to the work item page", func(t *testing.T) {
+		report := &models.MigrationReport{}
+		results := newCollector(report, &MigrationCheckpoint{}, 3)
+
+		results.failure(&models.WorkItem{ID: 9, URL: "https://dev.azure.com/org/project/_apis/wit/workItems/9"}, errors.New("boom"))
+		results.failure(&models.WorkItem{ID: 10}, errors.New("boom"))
+
+		assert.Equal(t, []string{
+			"Work Item 9 (https://dev.azure.com/org/project/_workitems/edit/9): boom",
+			"Work Item 10: boom",
+		}, report.Errors)
+		assert.Equal(t, "https://dev.azure.com/org/project/_workitems/edit/9", report.Mappings[0].AdoWorkItemURL)
+	})
+}
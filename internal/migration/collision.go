@@ -0,0 +1,70 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// Title collision policies for work items whose title matches an existing, non-migrated issue
+const (
+	CollisionPolicyCreate = "create" // Create the issue anyway and log a warning
+	CollisionPolicySkip   = "skip"   // Do not create the issue
+	CollisionPolicyLink   = "link"   // Create the issue and reference the existing one in its body
+)
+
+// findTitleCollision returns the number of an existing issue that was not created by the
+// migration and has the same title as the work item, or 0 when there is none
+func (e *Engine) findTitleCollision(ctx context.Context, workItem *models.WorkItem, issue *models.GitHubIssue) (int, error) {
+	candidates, err := e.githubClient.SearchIssuesByTitle(ctx, workItem.GetTitle())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, candidate := range candidates {
+		if strings.Contains(candidate.GetBody(), importedMarker) {
+			continue
+		}
+
+		title := strings.TrimSpace(candidate.GetTitle())
+		if strings.EqualFold(title, workItem.GetTitle()) || strings.EqualFold(title, issue.Title) {
+			return candidate.GetNumber(), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// applyCollisionPolicy checks the mapped issue against existing titles and applies the
+// configured policy. It returns the colliding issue number, and true when the work item
+// must be skipped.
+func (e *Engine) applyCollisionPolicy(ctx context.Context, workItem *models.WorkItem, issue *models.GitHubIssue) (int, bool, error) {
+	policy := e.config.TitleCollisionPolicy
+	if policy == "" {
+		return 0, false, nil
+	}
+
+	existing, err := e.findTitleCollision(ctx, workItem, issue)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to check title collisions: %w", err)
+	}
+	if existing == 0 {
+		return 0, false, nil
+	}
+
+	e.logger.Warn("Work item title collides with an existing issue",
+		"id", workItem.ID,
+		"issue", existing,
+		"policy", policy)
+
+	switch policy {
+	case CollisionPolicySkip:
+		return existing, true, nil
+	case CollisionPolicyLink:
+		issue.Body += fmt.Sprintf("\n\n> Possibly related to existing issue #%d with the same title", existing)
+	}
+
+	return existing, false, nil
+}
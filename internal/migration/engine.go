@@ -3,70 +3,147 @@ package migration
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/jlucaspains/adowi2gh/internal/ado"
+	"github.com/jlucaspains/adowi2gh/internal/archive"
 	"github.com/jlucaspains/adowi2gh/internal/config"
 	"github.com/jlucaspains/adowi2gh/internal/github"
+	"github.com/jlucaspains/adowi2gh/internal/lock"
 	"github.com/jlucaspains/adowi2gh/internal/models"
 )
 
+// ErrTimeBudgetExceeded is returned by Run when the run or a single work item used up its
+// time budget. The checkpoint is saved and the partial report is returned with it.
+var ErrTimeBudgetExceeded = errors.New("time budget exceeded")
+
+// ErrStaleDataset is returned by Run when the dataset lacks fields or comments the
+// current configuration needs. The dataset has to be fetched again.
+var ErrStaleDataset = errors.New("dataset does not hold what the configuration needs")
+
+// defaultDatasetPath is where the fetch phase writes work items when dataset_path is not configured
+const defaultDatasetPath = "./migration_dataset.ndjson.gz"
+
+// defaultLockTTL is the run lock lease used when lock_ttl is not configured
+const defaultLockTTL = 15 * time.Minute
+
+// batchPause is the pause between batches that keeps the run under the GitHub secondary rate limits
+var batchPause = 2 * time.Second
+
 type Engine struct {
-	adoClient    *ado.Client
-	githubClient *github.Client
+	adoClient    WorkItemSource
+	githubClient IssueTracker
 	mapper       *Mapper
 	config       *config.MigrationConfig
 	logger       *slog.Logger
 	report       *models.MigrationReport
 	checkpoint   *MigrationCheckpoint
+	results      *collector
+	runLock      *lock.Lock
 }
 
 type MigrationCheckpoint struct {
+	SchemaVersion   int                       `json:"schema_version"`
 	LastProcessedID int                       `json:"last_processed_id"`
 	ProcessedItems  []int                     `json:"processed_items"`
 	FailedItems     []int                     `json:"failed_items"`
 	Mappings        []models.MigrationMapping `json:"mappings"`
+	RetryQueue      []RetryEntry              `json:"retry_queue,omitempty"`
 	StartTime       time.Time                 `json:"start_time"`
 	LastUpdate      time.Time                 `json:"last_update"`
+	Stats           CheckpointStats           `json:"stats"`
+
+	loadedVersion int // Schema version the checkpoint had on disk before being migrated
 }
 
 func NewEngine(
-	adoClient *ado.Client,
-	githubClient *github.Client,
+	adoClient WorkItemSource,
+	githubClient IssueTracker,
 	mapper *Mapper,
 	config *config.MigrationConfig,
 	logger *slog.Logger,
 ) *Engine {
+	report := &models.MigrationReport{
+		RunLabel:  config.FieldMapping.RunLabel,
+		DryRun:    config.DryRun,
+		StartTime: time.Now(),
+		Mappings:  []models.MigrationMapping{},
+		Errors:    []string{},
+	}
+	checkpoint := &MigrationCheckpoint{
+		SchemaVersion:  CheckpointSchemaVersion,
+		ProcessedItems: []int{},
+		FailedItems:    []int{},
+		Mappings:       []models.MigrationMapping{},
+		StartTime:      time.Now(),
+	}
+
+	results := newCollector(report, checkpoint, config.MaxRetryAttempts)
+	// Traces are only useful to preview a mapping configuration
+	results.includeTrace = config.DryRun && config.TraceMapping
+	// Waiting out GitHub rate limits and Azure DevOps throttling shows up in the checkpoint statistics
+	if adoClient != nil {
+		adoClient.SetRateLimitObserver(results.rateLimitWait)
+	}
+	if githubClient != nil {
+		githubClient.SetRateLimitObserver(results.rateLimitWait)
+	}
+
 	return &Engine{
 		adoClient:    adoClient,
 		githubClient: githubClient,
 		mapper:       mapper,
 		config:       config,
 		logger:       logger,
-		report: &models.MigrationReport{
-			StartTime: time.Now(),
-			Mappings:  []models.MigrationMapping{},
-			Errors:    []string{},
-		},
-		checkpoint: &MigrationCheckpoint{
-			ProcessedItems: []int{},
-			FailedItems:    []int{},
-			Mappings:       []models.MigrationMapping{},
-			StartTime:      time.Now(),
-		},
+		report:       report,
+		checkpoint:   checkpoint,
+		results:      results,
 	}
 }
 
 func (e *Engine) Run(ctx context.Context) (*models.MigrationReport, error) {
 	e.logger.Info("Starting migration process...")
-	// Load checkpoint if resuming
-	if e.config.ResumeFromCheckpoint {
+
+	// Dry runs and fetch-only runs never write to GitHub, so only publishing runs take the lock
+	if !e.config.DryRun && !e.config.FetchOnly {
+		ttl := e.config.LockTTL
+		if ttl <= 0 {
+			ttl = defaultLockTTL
+		}
+
+		runLock, err := lock.Acquire(e.lockPath(), lock.DefaultOwner(), ttl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire run lock: %w", err)
+		}
+		e.runLock = runLock
+		stopRenewing := runLock.KeepAlive()
+		defer func() {
+			stopRenewing()
+			if err := runLock.Release(); err != nil {
+				e.logger.Warn("Failed to release run lock", "error", err)
+			}
+		}()
+	}
+
+	// Retrying requires the checkpoint that holds the retry queue
+	if e.config.RetryFailed {
 		if err := e.loadCheckpoint(); err != nil {
-			e.logger.Warn("Failed to load checkpoint", "error", err)
+			return nil, fmt.Errorf("failed to load retry queue: %w", err)
+		}
+	} else if e.config.ResumeFromCheckpoint {
+		// Without a checkpoint the run starts from the beginning. One that can't be read,
+		// or was written by a newer version, would lose the recorded progress.
+		if err := e.loadCheckpoint(); errors.Is(err, os.ErrNotExist) {
+			e.logger.Warn("No checkpoint to resume from, starting from the beginning", "path", e.checkpointPath())
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
 		}
 	}
 
@@ -74,11 +151,48 @@ func (e *Engine) Run(ctx context.Context) (*models.MigrationReport, error) {
 		return nil, fmt.Errorf("connection test failed: %w", err)
 	}
 
-	workItems, err := e.adoClient.GetWorkItems(ctx)
+	datasetPath := e.datasetPath()
+	if !e.config.PublishOnly {
+		// A dry run must not replace the dataset a real publish would read
+		if e.config.DryRun {
+			dir, err := os.MkdirTemp("", "adowi2gh-dataset-")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create temporary dataset directory: %w", err)
+			}
+			defer os.RemoveAll(dir)
+			datasetPath = filepath.Join(dir, filepath.Base(datasetPath))
+		}
+
+		written, err := e.fetch(ctx, datasetPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.config.FetchOnly {
+			e.report.TotalWorkItems = written
+			e.finishReport()
+			if e.config.DryRun {
+				e.logger.Info("Fetch completed, dry run kept the dataset unchanged", "dataset", e.datasetPath(), "fetched", written)
+			} else {
+				e.logger.Info("Fetch completed, publish with --publish-only", "dataset", datasetPath, "fetched", written)
+			}
+			return e.report, nil
+		}
+	}
+
+	workItems, err := e.loadDataset(datasetPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve work items: %w", err)
+		return nil, err
+	}
+	if e.config.RetryFailed {
+		workItems = e.filterRetries(workItems)
 	}
+	workItems = e.dedupWorkItems(workItems)
 	e.report.TotalWorkItems = len(workItems)
+	workItems, err = e.applyRetention(workItems)
+	if err != nil {
+		return nil, err
+	}
 	e.logger.Info("Found work items to migrate", "count", len(workItems))
 
 	if e.config.DryRun {
@@ -89,15 +203,171 @@ func (e *Engine) Run(ctx context.Context) (*models.MigrationReport, error) {
 	return e.performMigration(ctx, workItems)
 }
 
+func (e *Engine) datasetPath() string {
+	if e.config.DatasetPath == "" {
+		return defaultDatasetPath
+	}
+
+	return e.config.DatasetPath
+}
+
+// datasetContents describes what the publish phase reads from the dataset. Only the
+// fields the mapper reads are kept to bound memory on large migrations, unless work items
+// excluded by retention are archived: those are archived in full, comments included.
+func (e *Engine) datasetContents() archive.Contents {
+	if e.config.RetentionArchive != "" && !e.config.SkipClosedBefore.IsZero() {
+		return archive.Contents{Comments: true}
+	}
+
+	fields := e.mapper.RequiredFields()
+	if !e.config.SkipClosedBefore.IsZero() {
+		fields = append(fields, retentionFields...)
+	}
+
+	return archive.Contents{Fields: fields, Comments: e.config.IncludeComments}
+}
+
+// fetch runs the fetch phase: the queried work items and their comments are written
+// to the dataset at path so the publish phase never has to go back to Azure DevOps.
+// A resumed run appends to the existing dataset.
+func (e *Engine) fetch(ctx context.Context, path string) (int, error) {
+	e.logger.Info("Fetching work items from Azure DevOps", "dataset", path)
+
+	exporter := NewExporter(e.adoClient, e.config, e.logger)
+	exporter.SetContents(e.datasetContents())
+	written, err := exporter.Export(ctx, path, e.config.ResumeFromCheckpoint)
+	if err != nil {
+		return written, fmt.Errorf("fetch phase failed: %w", err)
+	}
+
+	return written, nil
+}
+
+// loadDataset reads the work items for the publish phase from the dataset at path. A
+// dataset fetched for a configuration that read fewer fields or no comments is rejected,
+// the mapping would silently miss them.
+func (e *Engine) loadDataset(path string) ([]*models.WorkItem, error) {
+	reader, err := archive.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer reader.Close()
+
+	if err := e.checkDataset(path, reader.Contents()); err != nil {
+		return nil, err
+	}
+
+	workItems := []*models.WorkItem{}
+	for {
+		workItem, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+		workItems = append(workItems, workItem)
+	}
+
+	return workItems, nil
+}
+
+// checkDataset verifies the dataset holds every field and the comments the configuration needs
+func (e *Engine) checkDataset(path string, contents *archive.Contents) error {
+	if contents == nil {
+		e.logger.Warn("Dataset does not record its fields, fetch it again if the mapping configuration changed", "dataset", path)
+		return nil
+	}
+
+	needed := e.datasetContents()
+	if len(needed.Fields) == 0 && len(contents.Fields) > 0 {
+		return fmt.Errorf("%w: %s only keeps the fields the mapping reads, fetch it again with all fields", ErrStaleDataset, path)
+	}
+	if missing := contents.Missing(needed.Fields); len(missing) > 0 {
+		return fmt.Errorf("%w: %s lacks %s, fetch it again", ErrStaleDataset, path, strings.Join(missing, ", "))
+	}
+	if needed.Comments && !contents.Comments {
+		return fmt.Errorf("%w: %s was fetched without comments, fetch it again", ErrStaleDataset, path)
+	}
+
+	return nil
+}
+
+// filterRetries keeps the work items waiting in the retry queue. Escalated items are
+// left out since they already failed max_retry_attempts times.
+func (e *Engine) filterRetries(workItems []*models.WorkItem) []*models.WorkItem {
+	pending := e.checkpoint.pendingRetries()
+
+	filtered := make([]*models.WorkItem, 0, len(pending))
+	for _, workItem := range workItems {
+		if pending[workItem.ID] {
+			filtered = append(filtered, workItem)
+		}
+	}
+
+	for _, entry := range e.checkpoint.RetryQueue {
+		if entry.Escalated {
+			e.logger.Warn("Skipping escalated work item, needs manual attention",
+				"id", entry.WorkItemID,
+				"attempts", entry.Attempts,
+				"category", entry.ErrorCategory,
+				"error", entry.LastError)
+		}
+	}
+	e.logger.Info("Retrying failed work items", "pending", len(pending), "found", len(filtered))
+
+	return filtered
+}
+
+// rejectedAssignees returns the mapped assignees of issue that GitHub would reject because
+// they can't be assigned in the repository
+func (e *Engine) rejectedAssignees(ctx context.Context, issue *models.GitHubIssue) ([]string, error) {
+	var rejected []string
+	for _, login := range issue.Assignees {
+		assignable, err := e.githubClient.IsAssignable(ctx, login)
+		if err != nil {
+			return nil, err
+		}
+		if !assignable {
+			rejected = append(rejected, login)
+		}
+	}
+
+	return rejected, nil
+}
+
+// dedupWorkItems drops repeated work item IDs, keeping the first occurrence. Overlapping
+// queries or a resumed fetch can list an item twice, and concurrent workers would then
+// create two issues for it.
+func (e *Engine) dedupWorkItems(workItems []*models.WorkItem) []*models.WorkItem {
+	seen := make(map[int]bool, len(workItems))
+	deduped := make([]*models.WorkItem, 0, len(workItems))
+	for _, workItem := range workItems {
+		if seen[workItem.ID] {
+			e.logger.Warn("Work item listed more than once, migrating it once", "id", workItem.ID)
+			continue
+		}
+		seen[workItem.ID] = true
+		deduped = append(deduped, workItem)
+	}
+
+	return deduped
+}
+
 func (e *Engine) testConnections(ctx context.Context) error {
 	e.logger.Info("Testing service connections...")
 
-	if err := e.adoClient.TestConnection(ctx); err != nil {
-		return fmt.Errorf("azure devops connection failed: %w", err)
+	// Each phase only talks to one service
+	if !e.config.PublishOnly {
+		if err := e.adoClient.TestConnection(ctx); err != nil {
+			return fmt.Errorf("azure devops connection failed: %w", err)
+		}
 	}
 
-	if err := e.githubClient.TestConnection(ctx); err != nil {
-		return fmt.Errorf("GitHub connection failed: %w", err)
+	if !e.config.FetchOnly {
+		if err := e.githubClient.TestConnection(ctx); err != nil {
+			return fmt.Errorf("GitHub connection failed: %w", err)
+		}
 	}
 
 	e.logger.Info("All connections successful")
@@ -115,14 +385,40 @@ func (e *Engine) performDryRun(ctx context.Context, workItems []*models.WorkItem
 
 		issue, err := e.mapper.MapWorkItemToIssue(workItem)
 		if err != nil {
-			e.logger.Error("Failed to map work item", "id", workItem.ID, "error", err)
-			e.report.FailedCount++
+			e.logger.Error("Failed to map work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
+			e.results.outcome(workItem, nil, 0, "failed", err.Error(), ErrorCategoryMapping)
+			continue
+		}
+
+		existing, skip, err := e.applyCollisionPolicy(ctx, workItem, issue)
+		if err != nil {
+			e.logger.Error("Title collision check failed for work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
+			e.results.outcome(workItem, issue, 0, "failed", err.Error(), categorizeError(err))
+			continue
+		}
+		if skip {
+			e.logger.Info("Work item would be skipped, title collides with existing issue", "id", workItem.ID, "issue", existing)
+			e.results.outcome(workItem, issue, existing, "skipped", fmt.Sprintf("Title collides with existing issue #%d", existing), "")
 			continue
 		}
 
 		if err := e.githubClient.ValidateLabels(ctx, issue.Labels); err != nil {
-			e.logger.Error("Label validation failed for work item", "id", workItem.ID, "error", err)
-			e.report.FailedCount++
+			e.logger.Error("Label validation failed for work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
+			e.results.outcome(workItem, issue, 0, "failed", err.Error(), categorizeError(err))
+			continue
+		}
+
+		rejected, err := e.rejectedAssignees(ctx, issue)
+		if err != nil {
+			e.logger.Error("Assignee check failed for work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
+			e.results.outcome(workItem, issue, 0, "failed", err.Error(), categorizeError(err))
+			continue
+		}
+		if len(rejected) > 0 {
+			e.logger.Warn("Work item would fail, assignees can't be assigned in the repository", "id", workItem.ID, "url", workItem.WebURL(), "assignees", rejected)
+			issue.Metadata["rejected_assignees"] = rejected
+			e.results.outcome(workItem, issue, 0, "failed",
+				fmt.Sprintf("Assignees can't be assigned in the repository: %s", strings.Join(rejected, ", ")), ErrorCategoryValidation)
 			continue
 		}
 
@@ -132,10 +428,9 @@ func (e *Engine) performDryRun(ctx context.Context, workItems []*models.WorkItem
 			"assignees", issue.Assignees,
 			"state", issue.State)
 
-		e.report.SuccessfulCount++
+		e.results.outcome(workItem, issue, 0, "success", "", "")
 	}
-	endTime := time.Now()
-	e.report.EndTime = &endTime
+	e.finishReport()
 	e.logger.Info("Dry run completed",
 		"successful", e.report.SuccessfulCount,
 		"failed", e.report.FailedCount)
@@ -145,6 +440,12 @@ func (e *Engine) performDryRun(ctx context.Context, workItems []*models.WorkItem
 
 func (e *Engine) performMigration(ctx context.Context, workItems []*models.WorkItem) (*models.MigrationReport, error) {
 	e.logger.Info("Starting actual migration...")
+	e.results.startRun(len(workItems))
+	// A retry run only covers the retry queue, the scope stays the one of the full runs
+	if !e.config.RetryFailed {
+		e.results.migrationScope(len(workItems))
+	}
+	usage := e.startRequestUsage()
 
 	batchSize := e.config.BatchSize
 	if batchSize <= 0 {
@@ -158,25 +459,47 @@ func (e *Engine) performMigration(ctx context.Context, workItems []*models.WorkI
 		}
 		batch := workItems[i:end]
 		e.logger.Info("Processing batch", "start", i+1, "end", end, "total", len(workItems))
+		e.warnOnRequestBudget(ctx, len(batch), usage)
+
+		err := e.processBatch(ctx, batch)
+
+		// Another run took over the lease: it would create the same issues and owns the
+		// checkpoint now
+		if lost := e.lockLost(); lost != nil {
+			return e.stopLockLost(lost)
+		}
+
+		// An interrupted run keeps what it migrated so far and continues with --resume
+		if ctx.Err() != nil {
+			return e.stopEarly("Migration interrupted, stopping", ctx.Err())
+		}
 
-		if err := e.processBatch(ctx, batch); err != nil {
+		if err != nil {
+			if errors.Is(err, ErrTimeBudgetExceeded) {
+				return e.stopEarly("Time budget exceeded, stopping migration", err)
+			}
+			if errors.Is(err, github.ErrRepositoryUnavailable) {
+				return e.stopEarly("Target repository is unavailable, stopping migration", err)
+			}
 			e.logger.Error("Batch processing failed", "error", err)
 			// Continue with next batch
 		}
 
+		e.results.batchFinished()
+
 		// Save checkpoint after each batch
 		if err := e.saveCheckpoint(); err != nil {
 			e.logger.Warn("Failed to save checkpoint", "error", err)
 		}
 
-		// Rate limiting
+		// Pause between batches to stay clear of rate limits. Only waits a rate limit
+		// actually imposed are counted in the statistics.
 		if len(batch) > 0 {
 			e.logger.Debug("Applying rate limiting...")
-			time.Sleep(time.Second * 2)
+			time.Sleep(batchPause)
 		}
 	}
-	endTime := time.Now()
-	e.report.EndTime = &endTime
+	e.finishReport()
 
 	e.logger.Info("Migration completed",
 		"successful", e.report.SuccessfulCount,
@@ -186,20 +509,143 @@ func (e *Engine) performMigration(ctx context.Context, workItems []*models.WorkI
 	return e.report, nil
 }
 
+// processBatch migrates the batch with up to concurrency workers. Once a time budget
+// runs out, the target repository becomes unavailable, the run lock is lost or ctx is
+// cancelled no further work items are started and the error is returned.
 func (e *Engine) processBatch(ctx context.Context, workItems []*models.WorkItem) error {
+	concurrency := e.config.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > len(workItems) {
+		concurrency = len(workItems)
+	}
+
+	jobs := make(chan *models.WorkItem)
+	var stopErr error
+	var stopOnce sync.Once
+	stop := make(chan struct{})
+	stopBatch := func(err error) {
+		stopOnce.Do(func() {
+			stopErr = err
+			close(stop)
+		})
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for workItem := range jobs {
+				if err := e.processWithBudget(ctx, workItem); err != nil {
+					stopBatch(err)
+				}
+			}
+		}()
+	}
+
+dispatch:
 	for _, workItem := range workItems {
-		if err := e.processWorkItem(ctx, workItem); err != nil {
-			e.logger.Error("Failed to process work item", "id", workItem.ID, "error", err)
-			e.recordFailure(workItem.ID, err.Error())
+		if err := e.lockLost(); err != nil {
+			stopBatch(err)
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			stopBatch(err)
+			break
+		}
+		if e.config.MaxRunDuration > 0 && time.Since(e.report.StartTime) >= e.config.MaxRunDuration {
+			stopBatch(fmt.Errorf("run exceeded %s: %w", e.config.MaxRunDuration, ErrTimeBudgetExceeded))
+			break
+		}
+
+		select {
+		case jobs <- workItem:
+		case <-stop:
+			break dispatch
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
+	return stopErr
+}
+
+// lockLost returns the error that ended the background renewal of the run lock
+func (e *Engine) lockLost() error {
+	if e.runLock == nil {
+		return nil
+	}
+
+	return e.runLock.Err()
+}
+
+// processWithBudget migrates a single work item, recording a failure. It only returns an
+// error when the item ran past max_item_duration, the target repository is unavailable
+// or ctx was cancelled.
+func (e *Engine) processWithBudget(ctx context.Context, workItem *models.WorkItem) error {
+	itemCtx, cancel := e.itemContext(ctx)
+	defer cancel()
+
+	start := time.Now()
+	err := e.processWorkItem(itemCtx, workItem)
+
+	// An interrupted item did not fail, it is migrated again when the run is resumed
+	if err != nil && ctx.Err() != nil {
+		e.logger.Warn("Work item interrupted", "id", workItem.ID, "error", err)
+		return ctx.Err()
+	}
+	e.results.itemDuration(time.Since(start))
+	if err != nil {
+		e.logger.Error("Failed to process work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
+		e.recordFailure(workItem, err)
+	}
+
+	if errors.Is(err, github.ErrRepositoryUnavailable) {
+		return err
+	}
+
+	if errors.Is(itemCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return fmt.Errorf("work item %d exceeded %s: %w", workItem.ID, e.config.MaxItemDuration, ErrTimeBudgetExceeded)
+	}
+
 	return nil
 }
 
+// itemContext bounds a single work item by max_item_duration when configured
+func (e *Engine) itemContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.config.MaxItemDuration > 0 {
+		return context.WithTimeout(ctx, e.config.MaxItemDuration)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// stopEarly checkpoints and ends the run early, so it can continue with --resume
+func (e *Engine) stopEarly(message string, err error) (*models.MigrationReport, error) {
+	e.logger.Warn(message, "reason", err)
+
+	if err := e.saveCheckpoint(); err != nil {
+		e.logger.Warn("Failed to save checkpoint", "error", err)
+	}
+	e.finishReport()
+
+	return e.report, err
+}
+
+// stopLockLost ends a run whose lease was taken over. The checkpoint is left to the run
+// that holds the lease now; the items migrated so far are in the report.
+func (e *Engine) stopLockLost(err error) (*models.MigrationReport, error) {
+	e.logger.Error("Run lock was lost, stopping without writing the checkpoint", "reason", err)
+	e.finishReport()
+
+	return e.report, fmt.Errorf("run lock lost: %w", err)
+}
+
 func (e *Engine) processWorkItem(ctx context.Context, workItem *models.WorkItem) error { // Check if already processed (for resume functionality)
-	if e.isAlreadyProcessed(workItem.ID) {
+	if e.results.alreadyProcessed(workItem.ID) {
 		e.logger.Debug("Work item already processed, skipping", "id", workItem.ID)
-		e.report.SkippedCount++
 		return nil
 	}
 
@@ -212,14 +658,22 @@ func (e *Engine) processWorkItem(ctx context.Context, workItem *models.WorkItem)
 	}
 	if len(existingIssues) > 0 {
 		e.logger.Info("Issue already exists for work item, skipping", "id", workItem.ID)
-		e.report.SkippedCount++
-		e.recordMapping(workItem.ID, existingIssues[0].GetNumber(), "skipped", "Issue already exists")
+		e.results.outcome(workItem, nil, existingIssues[0].GetNumber(), "skipped", "Issue already exists", "")
 		return nil
 	}
 
 	issue, err := e.mapper.MapWorkItemToIssue(workItem)
 	if err != nil {
-		return fmt.Errorf("failed to map work item: %w", err)
+		return &mappingError{err: fmt.Errorf("failed to map work item: %w", err)}
+	}
+
+	existing, skip, err := e.applyCollisionPolicy(ctx, workItem, issue)
+	if err != nil {
+		return err
+	}
+	if skip {
+		e.results.outcome(workItem, issue, existing, "skipped", fmt.Sprintf("Title collides with existing issue #%d", existing), "")
+		return nil
 	}
 
 	createdIssue, err := e.githubClient.CreateIssue(ctx, issue)
@@ -228,7 +682,7 @@ func (e *Engine) processWorkItem(ctx context.Context, workItem *models.WorkItem)
 	}
 	if e.config.IncludeComments {
 		if err := e.processComments(ctx, workItem, createdIssue.Number); err != nil {
-			e.logger.Warn("Failed to migrate comments for work item", "id", workItem.ID, "error", err)
+			e.logger.Warn("Failed to migrate comments for work item", "id", workItem.ID, "url", workItem.WebURL(), "error", err)
 		}
 	}
 
@@ -238,23 +692,23 @@ func (e *Engine) processWorkItem(ctx context.Context, workItem *models.WorkItem)
 		}
 	}
 
-	e.recordSuccess(workItem.ID, createdIssue.Number)
-	e.checkpoint.LastProcessedID = workItem.ID
-	e.checkpoint.LastUpdate = time.Now()
+	e.results.success(workItem, issue, createdIssue.Number)
 
 	return nil
 }
 
+// processComments publishes the comments fetched with the work item
 func (e *Engine) processComments(ctx context.Context, workItem *models.WorkItem, issueNumber int) error {
-	comments, err := e.adoClient.GetWorkItemComments(ctx, workItem.ID)
-	if err != nil {
-		return fmt.Errorf("failed to get work item comments: %w", err)
-	}
-
+	comments := workItem.Comments
 	if len(comments) == 0 {
 		return nil
 	}
 
+	if cutoff := e.commentCutoff(time.Now()); !cutoff.IsZero() {
+		comments = filterComments(comments, cutoff)
+		e.logger.Debug("Filtered comments by age", "id", workItem.ID, "since", cutoff, "kept", len(comments), "dropped", len(workItem.Comments)-len(comments))
+	}
+
 	e.logger.Debug("Migrating comments for work item", "count", len(comments), "id", workItem.ID)
 
 	githubComments := e.mapper.MapComments(comments)
@@ -267,45 +721,77 @@ func (e *Engine) processComments(ctx context.Context, workItem *models.WorkItem,
 	return nil
 }
 
-func (e *Engine) isAlreadyProcessed(workItemID int) bool {
-	for _, id := range e.checkpoint.ProcessedItems {
-		if id == workItemID {
-			return true
+// commentCutoff returns the creation time comments must not be older than, the later of
+// comments_since and comment_max_age_years. It is zero when comments aren't filtered.
+func (e *Engine) commentCutoff(now time.Time) time.Time {
+	cutoff := e.config.CommentsSince
+	if e.config.CommentMaxAgeYears > 0 {
+		if maxAge := now.AddDate(-e.config.CommentMaxAgeYears, 0, 0); maxAge.After(cutoff) {
+			cutoff = maxAge
 		}
 	}
-	return false
+
+	return cutoff
 }
 
-func (e *Engine) recordSuccess(workItemID, issueNumber int) {
-	e.report.SuccessfulCount++
-	e.checkpoint.ProcessedItems = append(e.checkpoint.ProcessedItems, workItemID)
-	e.recordMapping(workItemID, issueNumber, "success", "")
+// filterComments keeps the comments created at or after cutoff
+func filterComments(comments []models.WorkItemComment, cutoff time.Time) []models.WorkItemComment {
+	kept := make([]models.WorkItemComment, 0, len(comments))
+	for _, comment := range comments {
+		if !comment.CreatedDate.Before(cutoff) {
+			kept = append(kept, comment)
+		}
+	}
+
+	return kept
 }
 
-func (e *Engine) recordFailure(workItemID int, errorMsg string) {
-	e.report.FailedCount++
-	e.checkpoint.FailedItems = append(e.checkpoint.FailedItems, workItemID)
-	e.report.Errors = append(e.report.Errors, fmt.Sprintf("Work Item %d: %s", workItemID, errorMsg))
-	e.recordMapping(workItemID, 0, "failed", errorMsg)
+func (e *Engine) recordFailure(workItem *models.WorkItem, err error) {
+	entry := e.results.failure(workItem, err)
+	if entry.Escalated {
+		e.logger.Error("Work item failed repeatedly, escalating for manual attention",
+			"id", workItem.ID,
+			"url", workItem.WebURL(),
+			"attempts", entry.Attempts,
+			"category", entry.ErrorCategory)
+	}
 }
 
-func (e *Engine) recordMapping(workItemID, issueNumber int, status, errorMsg string) {
-	mapping := models.MigrationMapping{
-		AdoWorkItemID: workItemID,
-		GitHubIssueID: issueNumber,
-		MigratedAt:    time.Now(),
-		Status:        status,
-		ErrorMessage:  errorMsg,
+// finishReport stamps the end time and computes the report aggregations
+func (e *Engine) finishReport() {
+	endTime := time.Now()
+	e.report.EndTime = &endTime
+	e.results.sortReport()
+	e.report.ComputeBreakdown()
+	if e.githubClient != nil {
+		e.report.GitHubRequests = e.githubClient.RequestCounts()
+	}
+}
+
+func (e *Engine) checkpointPath() string {
+	if e.config.CheckpointPath == "" {
+		return "./migration_checkpoint.json"
 	}
 
-	e.report.Mappings = append(e.report.Mappings, mapping)
-	e.checkpoint.Mappings = append(e.checkpoint.Mappings, mapping)
+	return e.config.CheckpointPath
+}
+
+func (e *Engine) lockPath() string {
+	return e.checkpointPath() + ".lock"
 }
 
 func (e *Engine) saveCheckpoint() error {
-	checkpointPath := "./migration_checkpoint.json"
+	// Another run holds the lease and owns the checkpoint now
+	if err := e.lockLost(); err != nil {
+		return fmt.Errorf("checkpoint not written, run lock lost: %w", err)
+	}
+
+	checkpointPath := e.checkpointPath()
+	if err := os.MkdirAll(filepath.Dir(checkpointPath), 0750); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
 
-	data, err := json.MarshalIndent(e.checkpoint, "", "  ")
+	data, err := e.results.checkpointJSON()
 	if err != nil {
 		return fmt.Errorf("failed to marshal checkpoint: %w", err)
 	}
@@ -318,16 +804,17 @@ func (e *Engine) saveCheckpoint() error {
 }
 
 func (e *Engine) loadCheckpoint() error {
-	checkpointPath := "./migration_checkpoint.json"
-
-	data, err := os.ReadFile(checkpointPath)
+	checkpoint, err := LoadCheckpoint(e.checkpointPath())
 	if err != nil {
-		return fmt.Errorf("failed to read checkpoint file: %w", err)
+		return err
 	}
 
-	if err := json.Unmarshal(data, e.checkpoint); err != nil {
-		return fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	if checkpoint.loadedVersion < CheckpointSchemaVersion {
+		e.logger.Info("Upgraded checkpoint from an older version", "from_schema", checkpoint.loadedVersion, "to_schema", CheckpointSchemaVersion)
 	}
+
+	// The collector holds the checkpoint pointer, so replace its contents
+	*e.checkpoint = *checkpoint
 	e.logger.Info("Loaded checkpoint",
 		"processed_items", len(e.checkpoint.ProcessedItems),
 		"last_id", e.checkpoint.LastProcessedID)
@@ -0,0 +1,212 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/archive"
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+func TestDedupWorkItems(t *testing.T) {
+	engine := &Engine{logger: slog.New(slog.DiscardHandler)}
+	first := &models.WorkItem{ID: 1, Rev: 1}
+	duplicate := &models.WorkItem{ID: 1, Rev: 2}
+
+	deduped := engine.dedupWorkItems([]*models.WorkItem{first, {ID: 2}, duplicate, {ID: 3}, {ID: 2}})
+
+	ids := make([]int, 0, len(deduped))
+	for _, workItem := range deduped {
+		ids = append(ids, workItem.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Same(t, first, deduped[0])
+}
+
+func TestCommentCutoff(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		config   config.MigrationConfig
+		expected time.Time
+	}{
+		{name: "no filter", expected: time.Time{}},
+		{name: "since date", config: config.MigrationConfig{CommentsSince: since}, expected: since},
+		{name: "max age", config: config.MigrationConfig{CommentMaxAgeYears: 2}, expected: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "later of both", config: config.MigrationConfig{CommentsSince: since, CommentMaxAgeYears: 2}, expected: since},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{config: &tt.config}
+			assert.Equal(t, tt.expected, engine.commentCutoff(now))
+		})
+	}
+}
+
+func TestFilterComments(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	comments := []models.WorkItemComment{
+		{ID: 1, CreatedDate: cutoff.AddDate(-5, 0, 0)},
+		{ID: 2, CreatedDate: cutoff},
+		{ID: 3, CreatedDate: cutoff.AddDate(0, 1, 0)},
+	}
+
+	kept := filterComments(comments, cutoff)
+	assert.Len(t, kept, 2)
+	assert.Equal(t, 2, kept[0].ID)
+	assert.Equal(t, 3, kept[1].ID)
+}
+
+func TestApplyRetention(t *testing.T) {
+	cfg := &config.MigrationConfig{SkipClosedBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	report := &models.MigrationReport{}
+	engine := &Engine{
+		config:  cfg,
+		logger:  slog.New(slog.DiscardHandler),
+		mapper:  NewMapper(cfg, slog.New(slog.DiscardHandler)),
+		report:  report,
+		results: newCollector(report, &MigrationCheckpoint{}, 3),
+	}
+
+	workItem := func(id int, state, closed string) *models.WorkItem {
+		fields := map[string]interface{}{"System.State": state}
+		if closed != "" {
+			fields["Microsoft.VSTS.Common.ClosedDate"] = closed
+		}
+		return &models.WorkItem{ID: id, Fields: fields}
+	}
+
+	kept, err := engine.applyRetention([]*models.WorkItem{
+		workItem(1, "Closed", "2018-05-01T10:00:00Z"),
+		workItem(2, "Closed", "2021-05-01T10:00:00Z"),
+		workItem(3, "Active", "2018-05-01T10:00:00Z"),
+		workItem(4, "Done", ""),
+	})
+	require.NoError(t, err)
+
+	ids := make([]int, 0, len(kept))
+	for _, item := range kept {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []int{2, 3, 4}, ids)
+	assert.Equal(t, 1, report.ExcludedCount)
+	assert.Equal(t, 0, report.SkippedCount)
+	if assert.Len(t, report.Mappings, 1) {
+		assert.Equal(t, "excluded", report.Mappings[0].Status)
+		assert.Equal(t, "Closed on 2018-05-01, before skip_closed_before 2020-01-01", report.Mappings[0].ErrorMessage)
+	}
+}
+
+func TestApplyRetention_Archive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retained.ndjson.gz")
+	cfg := &config.MigrationConfig{
+		SkipClosedBefore: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		RetentionArchive: path,
+	}
+	newEngine := func() (*Engine, *models.MigrationReport) {
+		report := &models.MigrationReport{}
+		return &Engine{
+			config:  cfg,
+			logger:  slog.New(slog.DiscardHandler),
+			mapper:  NewMapper(cfg, slog.New(slog.DiscardHandler)),
+			report:  report,
+			results: newCollector(report, &MigrationCheckpoint{}, 3),
+		}, report
+	}
+	old := &models.WorkItem{
+		ID:       7,
+		Fields:   map[string]interface{}{"System.State": "Closed", "Microsoft.VSTS.Common.ClosedDate": "2018-05-01T10:00:00Z"},
+		Comments: []models.WorkItemComment{{ID: 1, Text: "kept in the archive"}},
+	}
+
+	// A second run must not archive the same item twice
+	for range 2 {
+		engine, report := newEngine()
+		kept, err := engine.applyRetention([]*models.WorkItem{old})
+		require.NoError(t, err)
+		assert.Empty(t, kept)
+		if assert.Len(t, report.Mappings, 1) {
+			assert.Contains(t, report.Mappings[0].ErrorMessage, "archived to "+path)
+		}
+	}
+
+	reader, err := archive.Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	archived, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 7, archived.ID)
+	require.Len(t, archived.Comments, 1)
+	assert.Equal(t, "kept in the archive", archived.Comments[0].Text)
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestProcessBatch_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Migration.Concurrency = 8
+	cfg.Migration.IncludeComments = true
+
+	tracker := newFakeTracker()
+	engine := newTestEngine(cfg, &fakeSource{}, tracker)
+
+	// Ten copies of the test work items under distinct IDs, in descending ID order
+	var workItems []*models.WorkItem
+	for copy := 9; copy >= 0; copy-- {
+		for _, workItem := range testWorkItems() {
+			workItem.ID += copy * 1000
+			workItems = append(workItems, workItem)
+		}
+	}
+
+	require.NoError(t, engine.processBatch(ctx, workItems))
+	engine.finishReport()
+
+	// One work item is assigned to a login the repository rejects
+	assert.Equal(t, 50, engine.report.SuccessfulCount)
+	assert.Equal(t, 10, engine.report.FailedCount)
+
+	issues := tracker.Issues()
+	require.Len(t, issues, 50)
+	created := map[int]bool{}
+	for _, issue := range issues {
+		assert.False(t, created[issue.SourceWIID], "work item %d migrated twice", issue.SourceWIID)
+		created[issue.SourceWIID] = true
+	}
+
+	require.Len(t, engine.report.Mappings, 60)
+	assert.True(t, slices.IsSortedFunc(engine.report.Mappings, func(a, b models.MigrationMapping) int {
+		return a.AdoWorkItemID - b.AdoWorkItemID
+	}))
+
+	data, err := engine.results.checkpointJSON()
+	require.NoError(t, err)
+	checkpoint := &MigrationCheckpoint{}
+	require.NoError(t, json.Unmarshal(data, checkpoint))
+	assert.Len(t, checkpoint.ProcessedItems, 50)
+	assert.True(t, slices.IsSorted(checkpoint.ProcessedItems))
+	mappingIDs := func(mappings []models.MigrationMapping) []int {
+		ids := make([]int, len(mappings))
+		for i, mapping := range mappings {
+			ids[i] = mapping.AdoWorkItemID
+		}
+		return ids
+	}
+	assert.Equal(t, mappingIDs(engine.report.Mappings), mappingIDs(checkpoint.Mappings))
+}
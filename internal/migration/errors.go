@@ -0,0 +1,64 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/google/go-github/v74/github"
+)
+
+// Error categories recorded on failed mappings
+const (
+	ErrorCategoryRateLimit  = "rate_limit"
+	ErrorCategoryAuth       = "auth"
+	ErrorCategoryNotFound   = "not_found"
+	ErrorCategoryValidation = "validation"
+	ErrorCategoryNetwork    = "network"
+	ErrorCategoryMapping    = "mapping"
+	ErrorCategoryOther      = "other"
+)
+
+// mappingError marks failures that happened while mapping a work item, before any API call
+type mappingError struct {
+	err error
+}
+
+func (e *mappingError) Error() string { return e.err.Error() }
+func (e *mappingError) Unwrap() error { return e.err }
+
+// categorizeError groups a failure into a coarse category for summaries and retry decisions
+func categorizeError(err error) string {
+	var rateLimitErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateLimitErr) || errors.As(err, &abuseErr) {
+		return ErrorCategoryRateLimit
+	}
+
+	var mapErr *mappingError
+	if errors.As(err, &mapErr) {
+		return ErrorCategoryMapping
+	}
+
+	var responseErr *github.ErrorResponse
+	if errors.As(err, &responseErr) && responseErr.Response != nil {
+		switch responseErr.Response.StatusCode {
+		case http.StatusTooManyRequests:
+			return ErrorCategoryRateLimit
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrorCategoryAuth
+		case http.StatusNotFound, http.StatusGone:
+			return ErrorCategoryNotFound
+		case http.StatusUnprocessableEntity:
+			return ErrorCategoryValidation
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorCategoryNetwork
+	}
+
+	return ErrorCategoryOther
+}
@@ -0,0 +1,107 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v74/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategorizeError(t *testing.T) {
+	responseError := func(status int) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}, Message: http.StatusText(status)}
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "primary rate limit",
+			err:      &github.RateLimitError{Message: "API rate limit exceeded"},
+			expected: ErrorCategoryRateLimit,
+		},
+		{
+			name:     "secondary rate limit",
+			err:      fmt.Errorf("failed to create issue: %w", &github.AbuseRateLimitError{Message: "slow down"}),
+			expected: ErrorCategoryRateLimit,
+		},
+		{
+			name:     "too many requests",
+			err:      responseError(http.StatusTooManyRequests),
+			expected: ErrorCategoryRateLimit,
+		},
+		{
+			name:     "unauthorized",
+			err:      responseError(http.StatusUnauthorized),
+			expected: ErrorCategoryAuth,
+		},
+		{
+			name:     "forbidden",
+			err:      fmt.Errorf("failed to create issue: %w", responseError(http.StatusForbidden)),
+			expected: ErrorCategoryAuth,
+		},
+		{
+			name:     "not found",
+			err:      responseError(http.StatusNotFound),
+			expected: ErrorCategoryNotFound,
+		},
+		{
+			name:     "gone",
+			err:      responseError(http.StatusGone),
+			expected: ErrorCategoryNotFound,
+		},
+		{
+			name:     "validation",
+			err:      responseError(http.StatusUnprocessableEntity),
+			expected: ErrorCategoryValidation,
+		},
+		{
+			name:     "network error",
+			err:      fmt.Errorf("failed to create issue: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}),
+			expected: ErrorCategoryNetwork,
+		},
+		{
+			name:     "deadline exceeded",
+			err:      fmt.Errorf("failed to create issue: %w", context.DeadlineExceeded),
+			expected: ErrorCategoryNetwork,
+		},
+		{
+			name:     "mapping",
+			err:      &mappingError{err: errors.New("no title")},
+			expected: ErrorCategoryMapping,
+		},
+		{
+			name:     "wrapped mapping",
+			err:      fmt.Errorf("work item 7: %w", &mappingError{err: errors.New("no title")}),
+			expected: ErrorCategoryMapping,
+		},
+		{
+			name:     "unhandled status",
+			err:      responseError(http.StatusInternalServerError),
+			expected: ErrorCategoryOther,
+		},
+		{
+			name:     "response without http response",
+			err:      &github.ErrorResponse{Message: "odd"},
+			expected: ErrorCategoryOther,
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			expected: ErrorCategoryOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, categorizeError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jlucaspains/adowi2gh/internal/archive"
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// Exporter writes work items and their comments to an offline archive
+type Exporter struct {
+	adoClient WorkItemSource
+	config    *config.MigrationConfig
+	logger    *slog.Logger
+	contents  archive.Contents
+}
+
+func NewExporter(adoClient WorkItemSource, config *config.MigrationConfig, logger *slog.Logger) *Exporter {
+	return &Exporter{
+		adoClient: adoClient,
+		config:    config,
+		logger:    logger,
+		contents:  archive.Contents{Comments: config.IncludeComments},
+	}
+}
+
+// SetContents chooses the fields kept on exported work items and whether comments are
+// fetched. By default every field is kept and comments follow include_comments.
+func (x *Exporter) SetContents(contents archive.Contents) {
+	x.contents = contents
+}
+
+// Export streams the queried work items into the archive at path and returns
+// how many items were written. Details are fetched and written one batch at a time.
+// When resume is set, items already present in the archive are skipped before their
+// details are fetched and new records are appended.
+func (x *Exporter) Export(ctx context.Context, path string, resume bool) (int, error) {
+	var writer *archive.Writer
+	exported := map[int]bool{}
+	var err error
+
+	if resume {
+		writer, exported, err = archive.Resume(path, x.contents)
+		if err == nil && len(exported) > 0 {
+			x.logger.Info("Resuming export", "path", path, "already_exported", len(exported))
+		}
+	} else {
+		writer, err = archive.Create(path, x.contents)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer writer.Close()
+
+	// No fields listed means all of them, which is a nil projection
+	var fields []string
+	if len(x.contents.Fields) > 0 {
+		fields = x.contents.Fields
+	}
+	x.adoClient.SetFieldProjection(fields)
+
+	workItemIds, err := x.adoClient.QueryWorkItemIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve work items: %w", err)
+	}
+
+	pending := make([]int, 0, len(workItemIds))
+	for _, id := range workItemIds {
+		if !exported[id] {
+			pending = append(pending, id)
+		}
+	}
+
+	written := 0
+	err = x.adoClient.StreamWorkItems(ctx, pending, func(workItems []*models.WorkItem) error {
+		for _, workItem := range workItems {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			x.logger.Info("Exporting work item", "current", written+1, "pending", len(pending), "id", workItem.ID)
+
+			if x.contents.Comments {
+				comments, err := x.adoClient.GetWorkItemComments(ctx, workItem.ID)
+				if err != nil {
+					return fmt.Errorf("failed to get comments for work item %d: %w", workItem.ID, err)
+				}
+				workItem.Comments = comments
+			}
+
+			if err := writer.Write(workItem); err != nil {
+				return err
+			}
+			written++
+		}
+
+		return nil
+	})
+	// A cancelled export keeps what it wrote, a resumed one picks up from there
+	if err != nil && ctx.Err() == nil {
+		return written, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return written, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return written, ctx.Err()
+}
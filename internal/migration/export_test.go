@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// failingCommentsSource fails to fetch the comments of one work item, like a run that
+// crashes partway through an export
+type failingCommentsSource struct {
+	*fakeSource
+	failID int
+}
+
+func (s *failingCommentsSource) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
+	if workItemID == s.failID {
+		return nil, errors.New("connection reset")
+	}
+
+	return s.fakeSource.GetWorkItemComments(ctx, workItemID)
+}
+
+// cancellingSource cancels the export once the comments of cancelAfter are fetched
+type cancellingSource struct {
+	*fakeSource
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (s *cancellingSource) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
+	if workItemID == s.cancelAfter {
+		defer s.cancel()
+	}
+
+	return s.fakeSource.GetWorkItemComments(ctx, workItemID)
+}
+
+func TestExporter_Export(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.MigrationConfig{IncludeComments: true}
+	logger := slog.New(slog.DiscardHandler)
+	path := filepath.Join(t.TempDir(), "export.ndjson.gz")
+
+	t.Run("writes every work item with its comments", func(t *testing.T) {
+		source := &fakeSource{}
+		written, err := NewExporter(source, cfg, logger).Export(ctx, path, false)
+		require.NoError(t, err)
+		assert.Equal(t, 6, written)
+
+		_, workItems := readDataset(t, path)
+		require.Len(t, workItems, 6)
+		assert.Equal(t, 101, workItems[0].ID)
+		assert.Len(t, workItems[0].Comments, 2)
+		assert.Equal(t, 106, workItems[5].ID)
+	})
+
+	t.Run("resume skips exported work items before fetching their details", func(t *testing.T) {
+		interrupted := &failingCommentsSource{fakeSource: &fakeSource{}, failID: 104}
+		written, err := NewExporter(interrupted, cfg, logger).Export(ctx, path, false)
+		require.ErrorContains(t, err, "failed to get comments for work item 104")
+		assert.Equal(t, 3, written)
+
+		source := &fakeSource{}
+		written, err = NewExporter(source, cfg, logger).Export(ctx, path, true)
+		require.NoError(t, err)
+		assert.Equal(t, 3, written)
+		assert.Equal(t, []int{104, 105, 106}, source.streamed)
+
+		_, workItems := readDataset(t, path)
+		ids := make([]int, len(workItems))
+		for i, workItem := range workItems {
+			ids[i] = workItem.ID
+		}
+		assert.Equal(t, []int{101, 102, 103, 104, 105, 106}, ids)
+	})
+
+	t.Run("a cancelled export keeps what it wrote", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		source := &cancellingSource{fakeSource: &fakeSource{}, cancelAfter: 102, cancel: cancel}
+		written, err := NewExporter(source, cfg, logger).Export(ctx, path, false)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 2, written)
+
+		_, workItems := readDataset(t, path)
+		assert.Len(t, workItems, 2)
+	})
+}
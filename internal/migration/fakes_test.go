@@ -0,0 +1,317 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v74/github"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/github"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// testCollaborators are the logins fakeTracker accepts as assignees
+var testCollaborators = map[string]bool{"ana-lima": true, "bortiz": true}
+
+// newTestConfig returns a migration configuration that keeps every file the run writes
+// in a temporary directory. Chris is mapped to a login the repository rejects.
+func newTestConfig(t *testing.T) *config.Config {
+	setBatchPause(t, 0)
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Migration.BatchSize = 10
+	cfg.Migration.Concurrency = 1
+	cfg.Migration.UserMapping = map[string]string{
+		"ana@fabrikam.com":   "ana-lima",
+		"ben@fabrikam.com":   "bortiz",
+		"chris@fabrikam.com": "cpark",
+	}
+	cfg.Migration.DatasetPath = filepath.Join(dir, "migration_dataset.ndjson.gz")
+	cfg.Migration.CheckpointPath = filepath.Join(dir, "migration_checkpoint.json")
+	cfg.Reports.Directory = filepath.Join(dir, "reports")
+	return cfg
+}
+
+// setBatchPause shortens the pause between batches until the test finishes
+func setBatchPause(t testing.TB, pause time.Duration) {
+	previous := batchPause
+	batchPause = pause
+	t.Cleanup(func() { batchPause = previous })
+}
+
+func newTestEngine(cfg *config.Config, source WorkItemSource, tracker IssueTracker) *Engine {
+	logger := slog.New(slog.DiscardHandler)
+	return NewEngine(source, tracker, NewMapper(&cfg.Migration, logger), &cfg.Migration, logger)
+}
+
+// testWorkItems returns six work items: 101 has two comments, 105 is assigned to a login
+// the repository rejects, and 103 and 106 were closed early in 2024
+func testWorkItems() []*models.WorkItem {
+	user := func(name string) map[string]interface{} {
+		return map[string]interface{}{"displayName": name, "uniqueName": strings.ToLower(name) + "@fabrikam.com"}
+	}
+	workItem := func(id int, workItemType, state string, fields map[string]interface{}) *models.WorkItem {
+		fields["System.Title"] = fmt.Sprintf("%s %d", workItemType, id)
+		fields["System.WorkItemType"] = workItemType
+		fields["System.State"] = state
+		fields["System.AreaPath"] = "Fabrikam\\Web"
+		fields["System.CreatedBy"] = user("Ben")
+		fields["System.CreatedDate"] = "2024-01-02T09:00:00Z"
+		fields["Microsoft.VSTS.Common.Priority"] = 2
+		return &models.WorkItem{ID: id, Rev: 1, URL: fmt.Sprintf("https://dev.azure.com/fabrikam/_apis/wit/workItems/%d", id), Fields: fields}
+	}
+	comment := func(id int, text string) models.WorkItemComment {
+		return models.WorkItemComment{ID: id, Text: text, CreatedBy: models.User{DisplayName: "Ana", UniqueName: "ana@fabrikam.com"}, CreatedDate: time.Date(2024, 3, id, 10, 0, 0, 0, time.UTC)}
+	}
+
+	first := workItem(101, "Bug", "Active", map[string]interface{}{
+		"System.AssignedTo":              user("Ana"),
+		"Microsoft.VSTS.Common.Severity": "2 - High",
+	})
+	first.Comments = []models.WorkItemComment{comment(1, "Reproduced on staging"), comment(2, "Fix is in review")}
+	last := workItem(106, "Task", "Done", map[string]interface{}{
+		"System.AssignedTo":                user("Ana"),
+		"Microsoft.VSTS.Common.ClosedDate": "2024-01-30T12:00:00Z",
+	})
+	last.Comments = []models.WorkItemComment{comment(3, "Done in the last sprint")}
+
+	return []*models.WorkItem{
+		first,
+		workItem(102, "User Story", "New", map[string]interface{}{}),
+		workItem(103, "Task", "Closed", map[string]interface{}{
+			"System.AssignedTo":                user("Ben"),
+			"Microsoft.VSTS.Common.ClosedDate": "2024-02-20T17:45:00Z",
+		}),
+		workItem(104, "Epic", "Active", map[string]interface{}{"System.AssignedTo": user("Dana")}),
+		workItem(105, "Bug", "Resolved", map[string]interface{}{"System.AssignedTo": user("Chris")}),
+		last,
+	}
+}
+
+// fakeSource serves testWorkItems in place of Azure DevOps, two work items per batch
+type fakeSource struct {
+	retainedFields []string
+	streamed       []int // IDs whose details were requested
+	observe        func(time.Duration)
+}
+
+func (s *fakeSource) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+// SetFieldProjection limits the fields kept on returned work items, like ado.Client does
+func (s *fakeSource) SetFieldProjection(fields []string) {
+	s.retainedFields = fields
+}
+
+func (s *fakeSource) SetRateLimitObserver(observe func(time.Duration)) {
+	s.observe = observe
+}
+
+func (s *fakeSource) QueryWorkItemIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+	for _, workItem := range testWorkItems() {
+		ids = append(ids, workItem.ID)
+	}
+
+	return ids, nil
+}
+
+// StreamWorkItems passes the requested work items to handle without comments, which are
+// served by GetWorkItemComments as Azure DevOps does
+func (s *fakeSource) StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
+	byID := map[int]*models.WorkItem{}
+	for _, workItem := range testWorkItems() {
+		workItem.Comments = nil
+		if s.retainedFields != nil {
+			workItem.RetainFields(s.retainedFields)
+		}
+		byID[workItem.ID] = workItem
+	}
+
+	for start := 0; start < len(workItemIds); start += 2 {
+		var batch []*models.WorkItem
+		for _, id := range workItemIds[start:min(start+2, len(workItemIds))] {
+			s.streamed = append(s.streamed, id)
+			batch = append(batch, byID[id])
+		}
+
+		if err := handle(batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeSource) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
+	for _, workItem := range testWorkItems() {
+		if workItem.ID == workItemID {
+			return workItem.Comments, nil
+		}
+	}
+
+	return nil, fmt.Errorf("work item %d not found", workItemID)
+}
+
+// fakeTracker keeps created issues in memory in place of a GitHub repository
+type fakeTracker struct {
+	mu       sync.Mutex
+	issues   []*models.GitHubIssue
+	requests map[string]int
+	observe  func(time.Duration)
+}
+
+func newFakeTracker() *fakeTracker {
+	return &fakeTracker{requests: map[string]int{}}
+}
+
+func (t *fakeTracker) count(category string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests[category]++
+}
+
+func (t *fakeTracker) TestConnection(ctx context.Context) error {
+	return nil
+}
+
+// CreateIssue stores issue under the next number. Like GitHub, it fails when an
+// assignee can't be assigned in the repository.
+func (t *fakeTracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	t.count(github.RequestCategoryREST)
+
+	for _, assignee := range issue.Assignees {
+		if !testCollaborators[strings.ToLower(assignee)] {
+			return nil, fmt.Errorf("failed to create issue: %w", &gh.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusUnprocessableEntity},
+				Message:  "Validation Failed",
+				Errors:   []gh.Error{{Resource: "Issue", Field: "assignees", Code: "invalid", Message: assignee + " can't be assigned"}},
+			})
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	created := *issue
+	created.Number = len(t.issues) + 1
+	created.State = "open"
+	created.Labels = append([]string{}, issue.Labels...)
+	created.Comments = nil
+	t.issues = append(t.issues, &created)
+
+	result := created
+	return &result, nil
+}
+
+func (t *fakeTracker) CreateIssueComment(ctx context.Context, issueNumber int, comment *models.GitHubComment) error {
+	t.count(github.RequestCategoryREST)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if issueNumber < 1 || issueNumber > len(t.issues) {
+		return fmt.Errorf("issue #%d not found", issueNumber)
+	}
+	issue := t.issues[issueNumber-1]
+	issue.Comments = append(issue.Comments, *comment)
+
+	return nil
+}
+
+func (t *fakeTracker) UpdateIssueState(ctx context.Context, issueNumber int, state string) error {
+	t.count(github.RequestCategoryREST)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if issueNumber < 1 || issueNumber > len(t.issues) {
+		return fmt.Errorf("issue #%d not found", issueNumber)
+	}
+	t.issues[issueNumber-1].State = state
+
+	return nil
+}
+
+// SearchIssues returns the issues already created for workItemID
+func (t *fakeTracker) SearchIssues(ctx context.Context, workItemID int) ([]*gh.Issue, error) {
+	return t.search(func(issue *models.GitHubIssue) bool { return issue.SourceWIID == workItemID }), nil
+}
+
+// SearchIssuesByTitle returns the issues with exactly title
+func (t *fakeTracker) SearchIssuesByTitle(ctx context.Context, title string) ([]*gh.Issue, error) {
+	return t.search(func(issue *models.GitHubIssue) bool { return issue.Title == title }), nil
+}
+
+func (t *fakeTracker) search(match func(*models.GitHubIssue) bool) []*gh.Issue {
+	t.count(github.RequestCategorySearch)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var found []*gh.Issue
+	for _, issue := range t.issues {
+		if match(issue) {
+			found = append(found, &gh.Issue{
+				Number: gh.Ptr(issue.Number),
+				Title:  gh.Ptr(issue.Title),
+				Body:   gh.Ptr(issue.Body),
+				State:  gh.Ptr(issue.State),
+			})
+		}
+	}
+
+	return found
+}
+
+func (t *fakeTracker) IsAssignable(ctx context.Context, login string) (bool, error) {
+	t.count(github.RequestCategoryREST)
+	return testCollaborators[strings.ToLower(login)], nil
+}
+
+func (t *fakeTracker) ValidateLabels(ctx context.Context, labels []string) error {
+	return nil
+}
+
+func (t *fakeTracker) RequestCounts() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int, len(t.requests))
+	for category, count := range t.requests {
+		counts[category] = count
+	}
+
+	return counts
+}
+
+func (t *fakeTracker) RemainingBudget(ctx context.Context) (*github.RateLimitBudget, error) {
+	return &github.RateLimitBudget{Core: 5000, Search: 30, GraphQL: 5000}, nil
+}
+
+func (t *fakeTracker) SetRateLimitObserver(observe func(time.Duration)) {
+	t.observe = observe
+}
+
+// Issues returns the created issues ordered by number
+func (t *fakeTracker) Issues() []models.GitHubIssue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issues := make([]models.GitHubIssue, 0, len(t.issues))
+	for _, issue := range t.issues {
+		issues = append(issues, *issue)
+	}
+
+	return issues
+}
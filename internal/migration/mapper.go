@@ -3,7 +3,10 @@ package migration
 import (
 	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jlucaspains/adowi2gh/internal/config"
@@ -12,29 +15,148 @@ import (
 	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
 )
 
+// bodySection renders an ADO field as a titled section of the issue body
+type bodySection struct {
+	Field string
+	Title string
+}
+
+// cmmiSections are the CMMI process template fields rendered when include_cmmi_fields is set
+var cmmiSections = []bodySection{
+	{Field: "Microsoft.VSTS.CMMI.Symptom", Title: "Symptom"},
+	{Field: "Microsoft.VSTS.CMMI.RootCause", Title: "Root Cause"},
+	{Field: "Microsoft.VSTS.CMMI.ProposedFix", Title: "Proposed Fix"},
+}
+
+// buildInfoFields are listed under Build Info so release traceability survives the migration
+var buildInfoFields = []bodySection{
+	{Field: "Microsoft.VSTS.Build.FoundIn", Title: "Found In"},
+	{Field: "Microsoft.VSTS.Build.IntegrationBuild", Title: "Integrated In"},
+}
+
+// defaultProvenanceLabel marks issues created by the migration when provenance_label is not configured
+const defaultProvenanceLabel = "migrated-from-ado"
+
+// needsOwnerLabel marks issues assigned to user_mapping_fallback because their assignee could not be mapped
+const needsOwnerLabel = "needs-owner"
+
+// defaultDateFormat is used for rendered dates when date_format is not configured
+const defaultDateFormat = "2006-01-02 15:04:05 MST"
+
+// strftimeDirectives maps strftime conversion specifiers to Go layout elements
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'p': "PM",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'j': "002",
+	'Z': "MST",
+	'z': "-0700",
+	'F': "2006-01-02",
+	'T': "15:04:05",
+}
+
 // Mapper handles the mapping between ADO work items and GitHub issues
 type Mapper struct {
-	config      *config.FieldMapping
-	userMapping map[string]string
-	logger      *slog.Logger
+	config       *config.FieldMapping
+	userMapping  map[string]string
+	userFallback string
+	logger       *slog.Logger
+	dateFormat   dateFormatter
+	labelAliases map[string]string // Lowercased generated label -> existing label
+	stateClasses map[string]string // Lowercased built-in state name -> GitHub state
+	warnedStates sync.Map          // Unknown states already warned about
+}
+
+// stateBundles are the default process state names per locale. English is always
+// recognized since collections often mix localized and English process templates.
+var stateBundles = map[string]struct{ open, closed []string }{
+	"en": {
+		open:   []string{"New", "Active", "Approved", "Committed", "In Progress", "Resolved"},
+		closed: []string{"Done", "Closed", "Removed"},
+	},
+	"de": {
+		open:   []string{"Neu", "Aktiv", "Genehmigt", "Zugesagt", "In Bearbeitung", "Gelöst"},
+		closed: []string{"Fertig", "Erledigt", "Geschlossen", "Entfernt"},
+	},
+	"fr": {
+		open:   []string{"Nouveau", "Actif", "Approuvé", "Validé", "En cours", "Résolu"},
+		closed: []string{"Terminé", "Fermé", "Supprimé"},
+	},
+	"es": {
+		open:   []string{"Nuevo", "Activo", "Aprobado", "Confirmado", "En curso", "Resuelto"},
+		closed: []string{"Hecho", "Cerrado", "Quitado"},
+	},
+	"pt": {
+		open:   []string{"Novo", "Ativo", "Aprovado", "Confirmado", "Em andamento", "Resolvido"},
+		closed: []string{"Concluído", "Fechado", "Removido"},
+	},
+}
+
+// stateClasses builds the lookup for the English bundle plus locale
+func stateClasses(locale string) map[string]string {
+	classes := make(map[string]string)
+	for _, name := range []string{"en", strings.ToLower(locale)} {
+		bundle := stateBundles[name]
+		for _, state := range bundle.open {
+			classes[strings.ToLower(state)] = "open"
+		}
+		for _, state := range bundle.closed {
+			classes[strings.ToLower(state)] = "closed"
+		}
+	}
+
+	return classes
 }
 
 func NewMapper(cfg *config.MigrationConfig, logger *slog.Logger) *Mapper {
 	return &Mapper{
-		config:      &cfg.FieldMapping,
-		userMapping: cfg.UserMapping,
-		logger:      logger,
+		config:       &cfg.FieldMapping,
+		userMapping:  cfg.UserMapping,
+		userFallback: cfg.UserMappingFallback,
+		logger:       logger,
+		dateFormat:   newDateFormatter(cfg.FieldMapping.DateFormat),
+		labelAliases: labelAliases(cfg.FieldMapping.LabelAliases),
+		stateClasses: stateClasses(cfg.FieldMapping.StateLocale),
 	}
 }
 
+func labelAliases(aliases map[string]string) map[string]string {
+	normalized := make(map[string]string, len(aliases))
+	for label, alias := range aliases {
+		normalized[strings.ToLower(label)] = alias
+	}
+
+	return normalized
+}
+
 func (m *Mapper) MapWorkItemToIssue(workItem *models.WorkItem) (*models.GitHubIssue, error) {
+	m.logDebugFields(workItem)
+
+	trace := &mappingTrace{}
 	issue := &models.GitHubIssue{
 		SourceWIID: workItem.ID,
-		Title:      workItem.GetTitle(),
+		Title:      m.mapTitle(workItem),
 		Body:       m.mapDescription(workItem),
-		State:      m.mapState(workItem.GetState()),
-		Labels:     m.mapLabels(workItem),
-		Assignees:  m.mapAssignees(workItem),
+		State:      m.mapState(workItem.GetState(), trace),
+		Labels:     m.mapLabels(workItem, trace),
+		Assignees:  m.mapAssignees(workItem, trace),
+
+		MilestoneTitle: m.mapMilestoneTitle(workItem),
+		ProjectFields:  m.mapProjectFields(workItem),
+		IssueType:      defaultIssueTypes[workItem.GetWorkItemType()],
+		SubIssueParent: workItem.GetParentID(),
 	}
 
 	// TODO: is metadata needed?
@@ -44,49 +166,321 @@ func (m *Mapper) MapWorkItemToIssue(workItem *models.WorkItem) (*models.GitHubIs
 	issue.Metadata["original_id"] = workItem.ID
 	issue.Metadata["original_type"] = workItem.GetWorkItemType()
 	issue.Metadata["original_url"] = workItem.URL
+	issue.Metadata["mapping_trace"] = trace.steps
+
+	m.logger.Debug("Mapping trace", "id", workItem.ID, "trace", trace.steps)
 
 	return issue, nil
 }
 
+// logDebugFields logs the raw values of the configured debug_fields exactly as ADO returned
+// them, ignoring field_applicability
+func (m *Mapper) logDebugFields(workItem *models.WorkItem) {
+	for _, field := range m.config.DebugFields {
+		value, present := workItem.Fields[field]
+		m.logger.Debug("Debug field",
+			"id", workItem.ID,
+			"field", field,
+			"present", present,
+			"type", fmt.Sprintf("%T", value),
+			"value", value)
+	}
+}
+
+// mappingTrace records which rule produced each mapping decision, to debug mapping configuration.
+// A nil trace records nothing.
+type mappingTrace struct {
+	steps []string
+}
+
+func (t *mappingTrace) add(format string, args ...interface{}) {
+	if t == nil {
+		return
+	}
+
+	t.steps = append(t.steps, fmt.Sprintf(format, args...))
+}
+
+// defaultIssueTypes maps work item types to the GitHub issue types available by default
+var defaultIssueTypes = map[string]string{
+	"Bug":                  "Bug",
+	"Task":                 "Task",
+	"User Story":           "Feature",
+	"Product Backlog Item": "Feature",
+	"Requirement":          "Feature",
+	"Feature":              "Feature",
+	"Epic":                 "Feature",
+}
+
+// mapMilestoneTitle uses the iteration the work item is planned in as milestone.
+// Items on the project root iteration are not planned and get no milestone.
+func (m *Mapper) mapMilestoneTitle(workItem *models.WorkItem) string {
+	iterationPath, _ := m.fieldValue(workItem, "System.IterationPath").(string)
+	segments := strings.Split(iterationPath, `\`)
+	if len(segments) < 2 {
+		return ""
+	}
+
+	return segments[len(segments)-1]
+}
+
+// mapProjectFields returns the values for GitHub Projects fields, keyed by field name
+func (m *Mapper) mapProjectFields(workItem *models.WorkItem) map[string]string {
+	fields := map[string]string{}
+
+	// The project prefix is the same for every item, so it is left out
+	if iterationPath, ok := m.fieldValue(workItem, "System.IterationPath").(string); ok {
+		if _, iteration, found := strings.Cut(iterationPath, `\`); found {
+			fields["Iteration"] = iteration
+		}
+	}
+
+	if storyPoints, ok := numberValue(m.fieldValue(workItem, "Microsoft.VSTS.Scheduling.StoryPoints")); ok {
+		fields["Story Points"] = strconv.FormatFloat(storyPoints, 'f', -1, 64)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+// importedMarker starts the body of every migrated issue
+const importedMarker = "Issue imported from Azure DevOps"
+
+// defaultLabelDelimiter separates the namespace and value of generated labels, as in "area:ui"
+const defaultLabelDelimiter = ":"
+
+// namespacedLabel joins a generated label namespace and value with the configured delimiter
+func (m *Mapper) namespacedLabel(namespace, value string) string {
+	delimiter := m.config.LabelDelimiter
+	if delimiter == "" {
+		delimiter = defaultLabelDelimiter
+	}
+
+	return namespace + delimiter + value
+}
+
+// defaultTypeEmoji prefixes titles when include_type_emoji is set and type_emoji has no entry for the type
+var defaultTypeEmoji = map[string]string{
+	"Bug":                  "🐛",
+	"User Story":           "✨",
+	"Product Backlog Item": "✨",
+	"Task":                 "🧩",
+}
+
+// mapTitle prefixes the title with the emoji configured for the work item type
+func (m *Mapper) mapTitle(workItem *models.WorkItem) string {
+	title := workItem.GetTitle()
+	if !m.config.IncludeTypeEmoji {
+		return title
+	}
+
+	workItemType := workItem.GetWorkItemType()
+	emoji, exists := m.config.TypeEmoji[workItemType]
+	if !exists {
+		emoji = defaultTypeEmoji[workItemType]
+	}
+	if emoji == "" {
+		return title
+	}
+
+	return emoji + " " + title
+}
+
+// RequiredFields returns the ADO field reference names read by the mapper
+// under the active configuration. Any other field can be dropped after retrieval.
+func (m *Mapper) RequiredFields() []string {
+	fields := []string{
+		"System.Title",
+		"System.Description",
+		"System.WorkItemType",
+		"System.State",
+		"System.AssignedTo",
+		"System.CreatedBy",
+		"System.CreatedDate",
+		"System.Tags",
+		"System.IterationPath",
+		"Microsoft.VSTS.Scheduling.StoryPoints",
+		"Microsoft.VSTS.Common.AcceptanceCriteria",
+		"Microsoft.VSTS.TCM.ReproSteps",
+	}
+
+	for _, field := range buildInfoFields {
+		fields = append(fields, field.Field)
+	}
+
+	if len(m.config.PriorityMapping) > 0 {
+		fields = append(fields, "Microsoft.VSTS.Common.Priority")
+	}
+
+	if m.config.IncludeSeverityLabel {
+		fields = append(fields, "Microsoft.VSTS.Common.Severity")
+	}
+
+	if m.config.IncludeAreaPathLabel {
+		fields = append(fields, "System.AreaPath")
+	}
+
+	if m.config.IncludeEngagement {
+		fields = append(fields, "System.CommentCount")
+	}
+
+	for _, rule := range m.config.NumericLabelBuckets {
+		fields = append(fields, rule.Field)
+	}
+
+	for field := range m.config.BooleanLabelMapping {
+		fields = append(fields, field)
+	}
+
+	if m.config.IncludeCMMIFields {
+		for _, section := range cmmiSections {
+			fields = append(fields, section.Field)
+		}
+	}
+
+	fields = append(fields, m.config.DebugFields...)
+
+	return fields
+}
+
 func (m *Mapper) mapDescription(workItem *models.WorkItem) string {
 	// TODO: add support for images
-	importedDescription := fmt.Sprintf("> Issue imported from Azure DevOps [#%d](%s)", workItem.ID, workItem.URL)
+	importedDescription := fmt.Sprintf("> %s [#%d](%s)", importedMarker, workItem.ID, workItem.URL)
 	description := workItem.GetDescription()
 
 	// Clean up HTML if present
 	description = importedDescription + "\n\n" + m.cleanHtmlContent(description)
 
 	// Add acceptance criteria if present
-	if acceptanceCriteria, ok := workItem.Fields["Microsoft.VSTS.Common.AcceptanceCriteria"].(string); ok && acceptanceCriteria != "" {
+	if acceptanceCriteria, ok := m.fieldValue(workItem, "Microsoft.VSTS.Common.AcceptanceCriteria").(string); ok && acceptanceCriteria != "" {
 		description += "\n\n## Acceptance Criteria\n" + m.cleanHtmlContent(acceptanceCriteria)
 	}
 
 	// Add reproduction steps if present
-	if repro, ok := workItem.Fields["Microsoft.VSTS.TCM.ReproSteps"].(string); ok && repro != "" {
+	if repro, ok := m.fieldValue(workItem, "Microsoft.VSTS.TCM.ReproSteps").(string); ok && repro != "" {
 		description += "\n\n## Reproduction Steps\n" + m.cleanHtmlContent(repro)
 	}
 
+	if m.config.IncludeCMMIFields {
+		description += m.mapSections(workItem, cmmiSections)
+	}
+
+	if buildInfo := m.mapBuildInfo(workItem); buildInfo != "" {
+		description += "\n\n## Build Info\n" + buildInfo
+	}
+
+	// Test management has no GitHub equivalent, keep links to the test artifacts for traceability
+	if testing := m.mapTestingLinks(workItem); testing != "" {
+		description += "\n\n## Testing\n" + testing
+	}
+
+	if m.config.IncludeEngagement {
+		description += "\n\n---\n" + m.mapEngagement(workItem)
+	}
+
 	return description
 }
 
-func (m *Mapper) mapState(adoState string) string {
+// mapEngagement summarizes how much activity the work item had in ADO so triagers
+// can judge the importance of migrated backlog items. ADO does not expose
+// follower counts through the work item API, so watchers are not included.
+func (m *Mapper) mapEngagement(workItem *models.WorkItem) string {
+	parts := []string{pluralize(workItem.Rev, "revision")}
+
+	if commentCount, ok := numberValue(m.fieldValue(workItem, "System.CommentCount")); ok {
+		parts = append(parts, pluralize(int(commentCount), "comment"))
+	}
+
+	return fmt.Sprintf("*Engagement in Azure DevOps: %s*", strings.Join(parts, " · "))
+}
+
+func (m *Mapper) mapSections(workItem *models.WorkItem, sections []bodySection) string {
+	var result string
+
+	for _, section := range sections {
+		if value, ok := m.fieldValue(workItem, section.Field).(string); ok && value != "" {
+			result += fmt.Sprintf("\n\n## %s\n%s", section.Title, m.cleanHtmlContent(value))
+		}
+	}
+
+	return result
+}
+
+// fieldValue returns the raw value of a field, or nil when field_applicability
+// restricts the field to other work item types
+func (m *Mapper) fieldValue(workItem *models.WorkItem, field string) interface{} {
+	if types, exists := m.config.FieldApplicability[field]; exists {
+		workItemType := workItem.GetWorkItemType()
+		applies := false
+		for _, allowedType := range types {
+			if strings.EqualFold(allowedType, workItemType) {
+				applies = true
+				break
+			}
+		}
+
+		if !applies {
+			return nil
+		}
+	}
+
+	return workItem.Fields[field]
+}
+
+func (m *Mapper) mapBuildInfo(workItem *models.WorkItem) string {
+	var lines []string
+
+	for _, field := range buildInfoFields {
+		if value, ok := m.fieldValue(workItem, field.Field).(string); ok && strings.TrimSpace(value) != "" {
+			lines = append(lines, fmt.Sprintf("- **%s:** %s", field.Title, strings.TrimSpace(value)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Mapper) mapTestingLinks(workItem *models.WorkItem) string {
+	var lines []string
+
+	for _, relation := range workItem.Relations {
+		if relation.Rel != models.RelationTestedBy {
+			continue
+		}
+
+		if id, ok := relation.WorkItemID(); ok {
+			lines = append(lines, fmt.Sprintf("- Test Case [#%d](%s)", id, models.WorkItemWebURL(relation.URL)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Mapper) mapState(adoState string, trace *mappingTrace) string {
 	if m.config.StateMapping != nil {
 		if githubState, exists := m.config.StateMapping[adoState]; exists {
+			trace.add("state %s: state_mapping[%q]", githubState, adoState)
 			return githubState
 		}
 	}
 
-	switch strings.ToLower(adoState) {
-	case "new", "active", "approved", "committed", "in progress", "resolved":
-		return "open"
-	case "done", "closed", "removed":
-		return "closed"
-	default:
-		return "open"
+	if githubState, exists := m.stateClasses[strings.ToLower(adoState)]; exists {
+		trace.add("state %s: built-in rule for %q", githubState, adoState)
+		return githubState
 	}
+
+	// Warn once per state, a localized or custom state would otherwise silently stay open
+	if _, warned := m.warnedStates.LoadOrStore(adoState, true); !warned {
+		m.logger.Warn("Unknown work item state, defaulting to open. Add it to state_mapping or set state_locale",
+			"state", adoState)
+	}
+	trace.add("state open: no rule for %q, defaulted", adoState)
+	return "open"
 }
 
-func (m *Mapper) mapLabels(workItem *models.WorkItem) []string {
+func (m *Mapper) mapLabels(workItem *models.WorkItem, trace *mappingTrace) []string {
 	var labels []string = []string{}
 
 	// Map work item type to labels
@@ -94,30 +488,58 @@ func (m *Mapper) mapLabels(workItem *models.WorkItem) []string {
 	if m.config.TypeMapping != nil {
 		if typeLabels, exists := m.config.TypeMapping[workItemType]; exists {
 			labels = append(labels, typeLabels...)
+			trace.add("labels %v: type_mapping[%q]", typeLabels, workItemType)
 		}
 	}
 
 	// Map priority to labels
-	if priority, ok := workItem.Fields["Microsoft.VSTS.Common.Priority"].(string); ok {
+	if priority, ok := m.fieldValue(workItem, "Microsoft.VSTS.Common.Priority").(string); ok {
 		if m.config.PriorityMapping != nil {
 			if priorityLabels, exists := m.config.PriorityMapping[priority]; exists {
 				labels = append(labels, priorityLabels...)
+				trace.add("labels %v: priority_mapping[%q]", priorityLabels, priority)
 			}
 		}
 	}
 
 	// Map severity to labels (for bugs)
-	if severity, ok := workItem.Fields["Microsoft.VSTS.Common.Severity"].(string); ok && m.config.IncludeSeverityLabel {
-		labels = append(labels, fmt.Sprintf("severity:%s", strings.ToLower(severity)))
+	if severity, ok := m.fieldValue(workItem, "Microsoft.VSTS.Common.Severity").(string); ok && m.config.IncludeSeverityLabel {
+		label := m.namespacedLabel("severity", strings.ToLower(severity))
+		labels = append(labels, label)
+		trace.add("label %s: include_severity_label", label)
 	}
 
 	// Add area path as label
-	if areaPath, ok := workItem.Fields["System.AreaPath"].(string); ok && m.config.IncludeAreaPathLabel {
+	if areaPath, ok := m.fieldValue(workItem, "System.AreaPath").(string); ok && m.config.IncludeAreaPathLabel {
 		// Extract the last part of the area path
 		pathParts := strings.Split(areaPath, "\\")
 		if len(pathParts) > 1 {
-			areaLabel := fmt.Sprintf("area:%s", strings.ToLower(pathParts[len(pathParts)-1]))
+			areaLabel := m.namespacedLabel("area", strings.ToLower(pathParts[len(pathParts)-1]))
 			labels = append(labels, areaLabel)
+			trace.add("label %s: include_area_path_label", areaLabel)
+		}
+	}
+
+	// Bucket numeric fields into labels
+	for _, rule := range m.config.NumericLabelBuckets {
+		if value, ok := numberValue(m.fieldValue(workItem, rule.Field)); ok {
+			if label := bucketLabel(rule.Buckets, value); label != "" {
+				labels = append(labels, label)
+				trace.add("label %s: numeric_label_buckets[%s] = %v", label, rule.Field, value)
+			}
+		}
+	}
+
+	// Toggle labels from boolean fields, in field order so label order is stable
+	booleanFields := make([]string, 0, len(m.config.BooleanLabelMapping))
+	for field := range m.config.BooleanLabelMapping {
+		booleanFields = append(booleanFields, field)
+	}
+	sort.Strings(booleanFields)
+	for _, field := range booleanFields {
+		if booleanValue(m.fieldValue(workItem, field)) {
+			labels = append(labels, m.config.BooleanLabelMapping[field])
+			trace.add("label %s: boolean_label_mapping[%s]", m.config.BooleanLabelMapping[field], field)
 		}
 	}
 
@@ -125,16 +547,55 @@ func (m *Mapper) mapLabels(workItem *models.WorkItem) []string {
 	tags := workItem.GetTags()
 	for _, tag := range tags {
 		if tag != "" {
-			labels = append(labels, strings.ToLower(strings.TrimSpace(tag)))
+			label := strings.ToLower(strings.TrimSpace(tag))
+			labels = append(labels, label)
+			trace.add("label %s: tag %q", label, tag)
 		}
 	}
 
+	if m.config.IncludeProvenance {
+		provenanceLabel := m.config.ProvenanceLabel
+		if provenanceLabel == "" {
+			provenanceLabel = defaultProvenanceLabel
+		}
+		labels = append(labels, provenanceLabel)
+		trace.add("label %s: include_provenance_label", provenanceLabel)
+	}
+
+	if m.config.RunLabel != "" {
+		labels = append(labels, m.config.RunLabel)
+		trace.add("label %s: run_label", m.config.RunLabel)
+	}
+
+	if m.assignsFallback(workItem) {
+		labels = append(labels, needsOwnerLabel)
+		trace.add("label %s: user_mapping_fallback", needsOwnerLabel)
+	}
+
+	labels = m.aliasLabels(labels, trace)
 	labels = m.deduplicateLabels(labels)
 
 	return labels
 }
 
-func (m *Mapper) mapAssignees(workItem *models.WorkItem) []string {
+// aliasLabels replaces generated labels with the existing repository labels configured
+// in label_aliases. GitHub label names are case-insensitive, so matching is too.
+func (m *Mapper) aliasLabels(labels []string, trace *mappingTrace) []string {
+	if len(m.labelAliases) == 0 {
+		return labels
+	}
+
+	for i, label := range labels {
+		if alias, exists := m.labelAliases[strings.ToLower(label)]; exists {
+			labels[i] = alias
+			trace.add("label %s replaced by %s: label_aliases", label, alias)
+		}
+	}
+
+	return labels
+}
+
+func (m *Mapper) mapAssignees(workItem *models.WorkItem, trace *mappingTrace) []string {
 	var assignees []string = []string{}
 
 	assignedTo := workItem.GetAssignedTo()
@@ -142,24 +603,56 @@ func (m *Mapper) mapAssignees(workItem *models.WorkItem) []string {
 		return assignees
 	}
 
-	// Try to map using configured user mapping first
-	if m.userMapping != nil {
-		// Try different variations of the user identifier
-		candidates := []string{
-			strings.ToLower(assignedTo.UniqueName),
-			strings.ToLower(assignedTo.Email),
-			strings.ToLower(assignedTo.DisplayName),
-		}
+	if githubUser, key, ok := m.mapUser(*assignedTo); ok {
+		assignees = append(assignees, githubUser)
+		trace.add("assignee %s: user_mapping[%q]", githubUser, key)
+	} else if m.userFallback != "" {
+		assignees = append(assignees, m.userFallback)
+		trace.add("assignee %s: user_mapping_fallback, %q has no user_mapping entry", m.userFallback, assignedTo.DisplayName)
+	} else {
+		trace.add("no assignee: %q has no user_mapping entry", assignedTo.DisplayName)
+	}
 
-		for _, candidate := range candidates {
-			if githubUser, exists := m.userMapping[candidate]; exists {
-				assignees = append(assignees, githubUser)
-				return assignees
-			}
+	return assignees
+}
+
+// assignsFallback reports whether the work item is assigned to someone without a
+// user_mapping entry and so goes to user_mapping_fallback. Unassigned items are left alone.
+func (m *Mapper) assignsFallback(workItem *models.WorkItem) bool {
+	if m.userFallback == "" {
+		return false
+	}
+
+	assignedTo := workItem.GetAssignedTo()
+	if assignedTo == nil {
+		return false
+	}
+
+	_, _, mapped := m.mapUser(*assignedTo)
+	return !mapped
+}
+
+// mapUser looks up the GitHub login of an ADO user in the configured user mapping.
+// key is the identifier that matched.
+func (m *Mapper) mapUser(user models.User) (login string, key string, ok bool) {
+	if m.userMapping == nil {
+		return "", "", false
+	}
+
+	// Try different variations of the user identifier
+	candidates := []string{
+		strings.ToLower(user.UniqueName),
+		strings.ToLower(user.Email),
+		strings.ToLower(user.DisplayName),
+	}
+
+	for _, candidate := range candidates {
+		if githubUser, exists := m.userMapping[candidate]; exists {
+			return githubUser, candidate, true
 		}
 	}
 
-	return assignees
+	return "", "", false
 }
 
 func (m *Mapper) MapComments(workItemComments []models.WorkItemComment) []models.GitHubComment {
@@ -172,17 +665,30 @@ func (m *Mapper) MapComments(workItemComments []models.WorkItemComment) []models
 		loc = time.Local
 	}
 
-	for _, comment := range workItemComments {
+	for i, comment := range workItemComments {
+		createdAt := comment.CreatedDate
 		githubComment := models.GitHubComment{
-			Body: m.cleanHtmlContent(comment.Text),
+			Body:            m.cleanHtmlContent(comment.Text),
+			SourceCommentID: comment.ID,
+			CreatedAt:       &createdAt,
+		}
+		if login, _, ok := m.mapUser(comment.CreatedBy); ok {
+			githubComment.OriginalAuthor = login
 		}
 
-		commentTime := comment.CreatedDate.In(loc).Format("2006-01-02 15:04:05 MST")
+		commentTime := m.dateFormat.Format(comment.CreatedDate.In(loc))
 		if comment.CreatedBy.DisplayName != "" {
 			githubComment.Body = fmt.Sprintf("*Comment by %s on %s:*\n\n%s",
 				comment.CreatedBy.DisplayName, commentTime, githubComment.Body)
 		}
 
+		if m.config.NumberComments {
+			githubComment.Body = fmt.Sprintf("**Comment #%d of %d**\n\n%s", i+1, len(workItemComments), githubComment.Body)
+		}
+
+		// Hidden marker keeps the original ADO comment ID for verification against the source
+		githubComment.Body = fmt.Sprintf("<!-- ado-comment-id: %d -->\n%s", comment.ID, githubComment.Body)
+
 		githubComments = append(githubComments, githubComment)
 	}
 
@@ -218,3 +724,121 @@ func (m *Mapper) deduplicateLabels(labels []string) []string {
 
 	return result
 }
+
+// numberValue reads a numeric field value. ADO returns numbers as JSON numbers,
+// but values coming from archives or custom integrations may be strings.
+func numberValue(fieldValue interface{}) (float64, bool) {
+	switch value := fieldValue.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// booleanValue reads a boolean field value. Besides real booleans, picklist
+// fields such as CMMI Blocked use "Yes"/"No" strings.
+func booleanValue(fieldValue interface{}) bool {
+	switch value := fieldValue.(type) {
+	case bool:
+		return value
+	case string:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "yes", "1":
+			return true
+		}
+	}
+	return false
+}
+
+// bucketLabel returns the label of the first bucket the value falls into
+func bucketLabel(buckets []config.LabelBucket, value float64) string {
+	for _, bucket := range buckets {
+		if bucket.Max == nil || value <= *bucket.Max {
+			return bucket.Label
+		}
+	}
+	return ""
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
+
+// dateFormatter renders dates using the configured date format
+type dateFormatter []datePart
+
+// datePart is either a Go layout or literal text copied verbatim
+type datePart struct {
+	text   string
+	layout bool
+}
+
+// newDateFormatter parses the configured date format.
+// Formats containing % are treated as strftime-style, anything else as a Go layout.
+// Literal text in strftime-style formats is kept apart from the directives so words
+// like "Monday" or digits are never read as Go layout elements.
+func newDateFormatter(format string) dateFormatter {
+	if format == "" {
+		return dateFormatter{{text: defaultDateFormat, layout: true}}
+	}
+	if !strings.Contains(format, "%") {
+		return dateFormatter{{text: format, layout: true}}
+	}
+
+	var parts dateFormatter
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, datePart{text: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			literal.WriteByte(format[i])
+			continue
+		}
+		i++
+		if format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+		element, ok := strftimeDirectives[format[i]]
+		if !ok {
+			// Keep unknown directives verbatim so the mistake is visible in the output
+			literal.WriteByte('%')
+			literal.WriteByte(format[i])
+			continue
+		}
+		flush()
+		parts = append(parts, datePart{text: element, layout: true})
+	}
+	flush()
+	return parts
+}
+
+// Format renders t, formatting layout parts and copying literal parts as-is
+func (f dateFormatter) Format(t time.Time) string {
+	var b strings.Builder
+	for _, part := range f {
+		if part.layout {
+			b.WriteString(t.Format(part.text))
+		} else {
+			b.WriteString(part.text)
+		}
+	}
+	return b.String()
+}
@@ -1,8 +1,10 @@
 package migration
 
 import (
+	"bytes"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -126,6 +128,229 @@ func TestMapWorkItemToIssue(t *testing.T) {
 	})
 }
 
+func TestMapCMMIFields(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	workItem := &models.WorkItem{
+		ID: 321,
+		Fields: map[string]interface{}{
+			"System.Title":                          "CMMI bug",
+			"System.WorkItemType":                   "Bug",
+			"Microsoft.VSTS.CMMI.Symptom":           "<p>App <strong>crashes</strong> on save</p>",
+			"Microsoft.VSTS.CMMI.RootCause":         "Coding Error",
+			"Microsoft.VSTS.CMMI.ProposedFix":       "<p>Check for null</p>",
+			"Microsoft.VSTS.Build.FoundIn":          "20240101.1",
+			"Microsoft.VSTS.Build.IntegrationBuild": "",
+		},
+	}
+
+	t.Run("renders sections when enabled", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeCMMIFields: true,
+				TimeZone:          "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Contains(t, issue.Body, "## Symptom\nApp **crashes** on save")
+		assert.Contains(t, issue.Body, "## Root Cause\nCoding Error")
+		assert.Contains(t, issue.Body, "## Proposed Fix\nCheck for null")
+		assert.Contains(t, mapper.RequiredFields(), "Microsoft.VSTS.CMMI.RootCause")
+	})
+
+	t.Run("omits sections by default", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.NotContains(t, issue.Body, "## Symptom")
+		assert.NotContains(t, issue.Body, "## Root Cause")
+		assert.NotContains(t, mapper.RequiredFields(), "Microsoft.VSTS.CMMI.RootCause")
+	})
+}
+
+func TestMapBuildInfo(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.MigrationConfig{
+		FieldMapping: config.FieldMapping{
+			TimeZone: "UTC",
+		},
+	}
+	mapper := NewMapper(cfg, logger)
+
+	t.Run("lists found in and integrated in builds", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 322,
+			Fields: map[string]interface{}{
+				"System.Title":                          "Bug with builds",
+				"System.WorkItemType":                   "Bug",
+				"Microsoft.VSTS.Build.FoundIn":          "20240101.1",
+				"Microsoft.VSTS.Build.IntegrationBuild": "20240102.3",
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Contains(t, issue.Body, "## Build Info\n- **Found In:** 20240101.1\n- **Integrated In:** 20240102.3")
+	})
+
+	t.Run("skips blank builds", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 323,
+			Fields: map[string]interface{}{
+				"System.Title":                          "Bug found in build",
+				"Microsoft.VSTS.Build.FoundIn":          "20240101.1",
+				"Microsoft.VSTS.Build.IntegrationBuild": "  ",
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Contains(t, issue.Body, "- **Found In:** 20240101.1")
+		assert.NotContains(t, issue.Body, "Integrated In")
+	})
+
+	t.Run("omits section without builds", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 324,
+			Fields: map[string]interface{}{
+				"System.Title": "Story",
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.NotContains(t, issue.Body, "## Build Info")
+	})
+}
+
+func TestMapEngagement(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	workItem := &models.WorkItem{
+		ID:  400,
+		Rev: 12,
+		Fields: map[string]interface{}{
+			"System.Title":        "Popular story",
+			"System.CommentCount": float64(1),
+		},
+	}
+
+	t.Run("adds footer when enabled", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeEngagement: true,
+				TimeZone:          "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.True(t, strings.HasSuffix(issue.Body, "\n\n---\n*Engagement in Azure DevOps: 12 revisions · 1 comment*"))
+		assert.Contains(t, mapper.RequiredFields(), "System.CommentCount")
+	})
+
+	t.Run("omits footer by default", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.NotContains(t, issue.Body, "Engagement in Azure DevOps")
+	})
+}
+
+func TestNumberValue(t *testing.T) {
+	value, ok := numberValue(float64(3.5))
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, value)
+
+	value, ok = numberValue(5)
+	assert.True(t, ok)
+	assert.Equal(t, float64(5), value)
+
+	value, ok = numberValue(" 8 ")
+	assert.True(t, ok)
+	assert.Equal(t, float64(8), value)
+
+	_, ok = numberValue("eight")
+	assert.False(t, ok)
+
+	_, ok = numberValue(true)
+	assert.False(t, ok)
+
+	_, ok = numberValue(nil)
+	assert.False(t, ok)
+}
+
+func TestMapTestingLinks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.MigrationConfig{
+		FieldMapping: config.FieldMapping{
+			TimeZone: "UTC",
+		},
+	}
+	mapper := NewMapper(cfg, logger)
+
+	t.Run("lists linked test cases", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID:  100,
+			URL: "https://dev.azure.com/org/_apis/wit/workItems/100",
+			Fields: map[string]interface{}{
+				"System.Title":        "Bug with tests",
+				"System.WorkItemType": "Bug",
+			},
+			Relations: []models.WorkItemRelation{
+				{Rel: "Microsoft.VSTS.Common.TestedBy-Forward", URL: "https://dev.azure.com/org/_apis/wit/workItems/200"},
+				{Rel: "System.LinkTypes.Hierarchy-Reverse", URL: "https://dev.azure.com/org/_apis/wit/workItems/50"},
+				{Rel: "Microsoft.VSTS.Common.TestedBy-Forward", URL: "https://dev.azure.com/org/_apis/wit/workItems/201"},
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Contains(t, issue.Body, "## Testing")
+		assert.Contains(t, issue.Body, "- Test Case [#200](https://dev.azure.com/org/_workitems/edit/200)")
+		assert.Contains(t, issue.Body, "- Test Case [#201](https://dev.azure.com/org/_workitems/edit/201)")
+		assert.NotContains(t, issue.Body, "#50")
+	})
+
+	t.Run("omits section without test links", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 101,
+			Fields: map[string]interface{}{
+				"System.Title": "Bug without tests",
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.NotContains(t, issue.Body, "## Testing")
+	})
+}
+
 func TestMapState(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
@@ -142,9 +367,9 @@ func TestMapState(t *testing.T) {
 		}
 		mapper := NewMapper(cfg, logger)
 
-		assert.Equal(t, "open", mapper.mapState("New"))
-		assert.Equal(t, "closed", mapper.mapState("Closed"))
-		assert.Equal(t, "closed", mapper.mapState("Done"))
+		assert.Equal(t, "open", mapper.mapState("New", nil))
+		assert.Equal(t, "closed", mapper.mapState("Closed", nil))
+		assert.Equal(t, "closed", mapper.mapState("Done", nil))
 	})
 
 	t.Run("default state mapping", func(t *testing.T) {
@@ -156,24 +381,48 @@ func TestMapState(t *testing.T) {
 		mapper := NewMapper(cfg, logger)
 
 		// Test default open states
-		assert.Equal(t, "open", mapper.mapState("New"))
-		assert.Equal(t, "open", mapper.mapState("Active"))
-		assert.Equal(t, "open", mapper.mapState("Approved"))
-		assert.Equal(t, "open", mapper.mapState("Committed"))
-		assert.Equal(t, "open", mapper.mapState("In Progress"))
-		assert.Equal(t, "open", mapper.mapState("Resolved"))
+		assert.Equal(t, "open", mapper.mapState("New", nil))
+		assert.Equal(t, "open", mapper.mapState("Active", nil))
+		assert.Equal(t, "open", mapper.mapState("Approved", nil))
+		assert.Equal(t, "open", mapper.mapState("Committed", nil))
+		assert.Equal(t, "open", mapper.mapState("In Progress", nil))
+		assert.Equal(t, "open", mapper.mapState("Resolved", nil))
 
 		// Test default closed states
-		assert.Equal(t, "closed", mapper.mapState("Done"))
-		assert.Equal(t, "closed", mapper.mapState("Closed"))
-		assert.Equal(t, "closed", mapper.mapState("Removed"))
+		assert.Equal(t, "closed", mapper.mapState("Done", nil))
+		assert.Equal(t, "closed", mapper.mapState("Closed", nil))
+		assert.Equal(t, "closed", mapper.mapState("Removed", nil))
 
 		// Test case insensitive
-		assert.Equal(t, "open", mapper.mapState("new"))
-		assert.Equal(t, "closed", mapper.mapState("done"))
+		assert.Equal(t, "open", mapper.mapState("new", nil))
+		assert.Equal(t, "closed", mapper.mapState("done", nil))
 
 		// Test unknown state defaults to open
-		assert.Equal(t, "open", mapper.mapState("Unknown"))
+		assert.Equal(t, "open", mapper.mapState("Unknown", nil))
+	})
+
+	t.Run("localized state bundle", func(t *testing.T) {
+		var logs bytes.Buffer
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				StateLocale:  "de",
+				StateMapping: map[string]string{"Fertig": "open"},
+			},
+		}
+		mapper := NewMapper(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+
+		assert.Equal(t, "open", mapper.mapState("Aktiv", nil))
+		assert.Equal(t, "closed", mapper.mapState("geschlossen", nil))
+		assert.Equal(t, "closed", mapper.mapState("Done", nil))
+		// Explicit mapping wins over the bundle
+		assert.Equal(t, "open", mapper.mapState("Fertig", nil))
+		assert.Empty(t, logs.String())
+
+		// Unknown states are warned about once
+		assert.Equal(t, "open", mapper.mapState("Blockiert", nil))
+		assert.Equal(t, "open", mapper.mapState("Blockiert", nil))
+		assert.Equal(t, 1, strings.Count(logs.String(), "level=WARN"))
+		assert.Contains(t, logs.String(), "state=Blockiert")
 	})
 }
 
@@ -198,7 +447,7 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		assert.Contains(t, labels, "bug")
 		assert.Contains(t, labels, "defect")
 	})
@@ -222,7 +471,7 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		assert.Contains(t, labels, "priority:critical")
 	})
 
@@ -242,10 +491,108 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		assert.Contains(t, labels, "severity:1 - critical")
 	})
 
+	t.Run("severity restricted by field applicability", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeSeverityLabel: true,
+				FieldApplicability: map[string][]string{
+					"Microsoft.VSTS.Common.Severity": {"Bug", "Incident"},
+				},
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		bug := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType":            "bug",
+				"Microsoft.VSTS.Common.Severity": "2 - High",
+			},
+		}
+		story := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType":            "User Story",
+				"Microsoft.VSTS.Common.Severity": "2 - High",
+			},
+		}
+
+		assert.Contains(t, mapper.mapLabels(bug, nil), "severity:2 - high")
+		assert.NotContains(t, mapper.mapLabels(story, nil), "severity:2 - high")
+	})
+
+	t.Run("with numeric label buckets", func(t *testing.T) {
+		small, medium := 2.0, 5.0
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				NumericLabelBuckets: []config.NumericLabelRule{
+					{
+						Field: "Microsoft.VSTS.Scheduling.StoryPoints",
+						Buckets: []config.LabelBucket{
+							{Max: &small, Label: "size:S"},
+							{Max: &medium, Label: "size:M"},
+							{Label: "size:XL"},
+						},
+					},
+				},
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		labelsFor := func(points interface{}) []string {
+			return mapper.mapLabels(&models.WorkItem{
+				Fields: map[string]interface{}{
+					"System.WorkItemType":                   "User Story",
+					"Microsoft.VSTS.Scheduling.StoryPoints": points,
+				},
+			}, nil)
+		}
+
+		assert.Contains(t, labelsFor(float64(1)), "size:S")
+		assert.Contains(t, labelsFor(float64(2)), "size:S")
+		assert.Contains(t, labelsFor(float64(3)), "size:M")
+		assert.Contains(t, labelsFor(float64(21)), "size:XL")
+		assert.Empty(t, labelsFor(nil))
+		assert.Contains(t, mapper.RequiredFields(), "Microsoft.VSTS.Scheduling.StoryPoints")
+	})
+
+	t.Run("with boolean label mapping", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				BooleanLabelMapping: map[string]string{
+					"Custom.CustomerImpacting":    "customer-impacting",
+					"Microsoft.VSTS.CMMI.Blocked": "blocked",
+				},
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		labels := mapper.mapLabels(&models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType":         "Bug",
+				"Custom.CustomerImpacting":    true,
+				"Microsoft.VSTS.CMMI.Blocked": "Yes",
+			},
+		}, nil)
+		assert.Contains(t, labels, "customer-impacting")
+		assert.Contains(t, labels, "blocked")
+
+		labels = mapper.mapLabels(&models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType":         "Bug",
+				"Custom.CustomerImpacting":    false,
+				"Microsoft.VSTS.CMMI.Blocked": "No",
+			},
+		}, nil)
+		assert.NotContains(t, labels, "customer-impacting")
+		assert.NotContains(t, labels, "blocked")
+	})
+
 	t.Run("with area path label", func(t *testing.T) {
 		cfg := &config.MigrationConfig{
 			FieldMapping: config.FieldMapping{
@@ -262,10 +609,60 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		assert.Contains(t, labels, "area:ui")
 	})
 
+	t.Run("with label aliases", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TypeMapping: map[string][]string{
+					"User Story": {"enhancement"},
+				},
+				LabelAliases: map[string]string{
+					"Enhancement": "feature-request",
+					"ui":          "feature-request",
+				},
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		workItem := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType": "User Story",
+				"System.Tags":         "UI; backend",
+			},
+		}
+
+		labels := mapper.mapLabels(workItem, nil)
+		assert.Equal(t, []string{"feature-request", "backend"}, labels)
+	})
+
+	t.Run("with custom label delimiter", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeSeverityLabel: true,
+				IncludeAreaPathLabel: true,
+				LabelDelimiter:       "/",
+				TimeZone:             "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		workItem := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType":            "Bug",
+				"System.AreaPath":                "MyProject\\Frontend\\UI",
+				"Microsoft.VSTS.Common.Severity": "2 - High",
+			},
+		}
+
+		labels := mapper.mapLabels(workItem, nil)
+		assert.Contains(t, labels, "area/ui")
+		assert.Contains(t, labels, "severity/2 - high")
+	})
+
 	t.Run("with tags", func(t *testing.T) {
 		cfg := &config.MigrationConfig{
 			FieldMapping: config.FieldMapping{
@@ -281,12 +678,55 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		assert.Contains(t, labels, "urgent")
 		assert.Contains(t, labels, "needs-review")
 		assert.Contains(t, labels, "customer-reported")
 	})
 
+	t.Run("with provenance label", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType": "Bug",
+			},
+		}
+
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeProvenance: true,
+				TimeZone:          "UTC",
+			},
+		}
+		assert.Contains(t, NewMapper(cfg, logger).mapLabels(workItem, nil), "migrated-from-ado")
+
+		cfg.FieldMapping.ProvenanceLabel = "from-azure-boards"
+		labels := NewMapper(cfg, logger).mapLabels(workItem, nil)
+		assert.Contains(t, labels, "from-azure-boards")
+		assert.NotContains(t, labels, "migrated-from-ado")
+
+		cfg.FieldMapping.IncludeProvenance = false
+		assert.NotContains(t, NewMapper(cfg, logger).mapLabels(workItem, nil), "from-azure-boards")
+	})
+
+	t.Run("with run label", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeProvenance: true,
+				RunLabel:          "ado-migration-2025-01",
+				TimeZone:          "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		labels := mapper.mapLabels(&models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.WorkItemType": "Bug",
+			},
+		}, nil)
+		assert.Contains(t, labels, "migrated-from-ado")
+		assert.Contains(t, labels, "ado-migration-2025-01")
+	})
+
 	t.Run("deduplicates labels", func(t *testing.T) {
 		cfg := &config.MigrationConfig{
 			FieldMapping: config.FieldMapping{
@@ -305,7 +745,7 @@ func TestMapLabels(t *testing.T) {
 			},
 		}
 
-		labels := mapper.mapLabels(workItem)
+		labels := mapper.mapLabels(workItem, nil)
 		// Should only contain "bug" once
 		bugCount := 0
 		for _, label := range labels {
@@ -343,7 +783,7 @@ func TestMapAssignees(t *testing.T) {
 			},
 		}
 
-		assignees := mapper.mapAssignees(workItem)
+		assignees := mapper.mapAssignees(workItem, nil)
 		assert.Equal(t, []string{"johndoe"}, assignees)
 	})
 
@@ -369,7 +809,7 @@ func TestMapAssignees(t *testing.T) {
 			},
 		}
 
-		assignees := mapper.mapAssignees(workItem)
+		assignees := mapper.mapAssignees(workItem, nil)
 		assert.Equal(t, []string{"johndoe"}, assignees)
 	})
 
@@ -395,7 +835,7 @@ func TestMapAssignees(t *testing.T) {
 			},
 		}
 
-		assignees := mapper.mapAssignees(workItem)
+		assignees := mapper.mapAssignees(workItem, nil)
 		assert.Equal(t, []string{"johndoe"}, assignees)
 	})
 
@@ -421,7 +861,7 @@ func TestMapAssignees(t *testing.T) {
 			},
 		}
 
-		assignees := mapper.mapAssignees(workItem)
+		assignees := mapper.mapAssignees(workItem, nil)
 		assert.Empty(t, assignees)
 	})
 
@@ -437,9 +877,46 @@ func TestMapAssignees(t *testing.T) {
 			Fields: map[string]interface{}{},
 		}
 
-		assignees := mapper.mapAssignees(workItem)
+		assignees := mapper.mapAssignees(workItem, nil)
 		assert.Empty(t, assignees)
 	})
+
+	t.Run("unmapped user goes to fallback", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			UserMapping: map[string]string{
+				"john.doe@example.com": "johndoe",
+			},
+			UserMappingFallback: "triage-bot",
+		}
+		mapper := NewMapper(cfg, logger)
+
+		unmapped := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.AssignedTo": map[string]interface{}{
+					"displayName": "Jane Smith",
+					"uniqueName":  "jane.smith@example.com",
+				},
+			},
+		}
+		assert.Equal(t, []string{"triage-bot"}, mapper.mapAssignees(unmapped, nil))
+		assert.Contains(t, mapper.mapLabels(unmapped, nil), "needs-owner")
+
+		mapped := &models.WorkItem{
+			Fields: map[string]interface{}{
+				"System.AssignedTo": map[string]interface{}{
+					"displayName": "John Doe",
+					"uniqueName":  "john.doe@example.com",
+				},
+			},
+		}
+		assert.Equal(t, []string{"johndoe"}, mapper.mapAssignees(mapped, nil))
+		assert.NotContains(t, mapper.mapLabels(mapped, nil), "needs-owner")
+
+		// Unassigned items stay unassigned
+		unassigned := &models.WorkItem{Fields: map[string]interface{}{}}
+		assert.Empty(t, mapper.mapAssignees(unassigned, nil))
+		assert.NotContains(t, mapper.mapLabels(unassigned, nil), "needs-owner")
+	})
 }
 
 func TestMapComments(t *testing.T) {
@@ -473,6 +950,44 @@ func TestMapComments(t *testing.T) {
 		assert.Contains(t, githubComments[0].Body, "2024-01-15")
 	})
 
+	t.Run("carries source comment metadata", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone: "UTC",
+			},
+			UserMapping: map[string]string{
+				"jane@example.com": "janesmith",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		createdDate := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+		comments := []models.WorkItemComment{
+			{
+				ID:          7,
+				Text:        "Mapped author",
+				CreatedDate: createdDate,
+				CreatedBy:   models.User{DisplayName: "Jane Smith", UniqueName: "jane@example.com"},
+			},
+			{
+				ID:          8,
+				Text:        "Unmapped author",
+				CreatedDate: createdDate,
+				CreatedBy:   models.User{DisplayName: "John Doe"},
+			},
+		}
+
+		githubComments := mapper.MapComments(comments)
+
+		require.Len(t, githubComments, 2)
+		assert.Equal(t, 7, githubComments[0].SourceCommentID)
+		assert.Equal(t, "janesmith", githubComments[0].OriginalAuthor)
+		require.NotNil(t, githubComments[0].CreatedAt)
+		assert.Equal(t, createdDate, *githubComments[0].CreatedAt)
+		assert.Equal(t, 8, githubComments[1].SourceCommentID)
+		assert.Empty(t, githubComments[1].OriginalAuthor)
+	})
+
 	t.Run("handles invalid timezone gracefully", func(t *testing.T) {
 		cfg := &config.MigrationConfig{
 			FieldMapping: config.FieldMapping{
@@ -498,6 +1013,68 @@ func TestMapComments(t *testing.T) {
 		assert.Contains(t, githubComments[0].Body, "Comment by John Doe")
 	})
 
+	t.Run("uses configured date format", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone:   "UTC",
+				DateFormat: "%d/%m/%Y %H:%M",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		comments := []models.WorkItemComment{
+			{
+				Text:        "Dated comment",
+				CreatedDate: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+				CreatedBy: models.User{
+					DisplayName: "Jane Smith",
+				},
+			},
+		}
+
+		githubComments := mapper.MapComments(comments)
+		require.Len(t, githubComments, 1)
+		assert.Contains(t, githubComments[0].Body, "Comment by Jane Smith on 15/01/2024 10:30:")
+	})
+
+	t.Run("includes comment id marker and numbering", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone:       "UTC",
+				NumberComments: true,
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		comments := []models.WorkItemComment{
+			{ID: 1001, Text: "First", CreatedBy: models.User{DisplayName: "Jane Smith"}},
+			{ID: 1002, Text: "Second", CreatedBy: models.User{DisplayName: "John Doe"}},
+		}
+
+		githubComments := mapper.MapComments(comments)
+		require.Len(t, githubComments, 2)
+		assert.True(t, strings.HasPrefix(githubComments[0].Body, "<!-- ado-comment-id: 1001 -->\n**Comment #1 of 2**"))
+		assert.True(t, strings.HasPrefix(githubComments[1].Body, "<!-- ado-comment-id: 1002 -->\n**Comment #2 of 2**"))
+	})
+
+	t.Run("numbering is off by default", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		comments := []models.WorkItemComment{
+			{ID: 1001, Text: "First", CreatedBy: models.User{DisplayName: "Jane Smith"}},
+		}
+
+		githubComments := mapper.MapComments(comments)
+		require.Len(t, githubComments, 1)
+		assert.Contains(t, githubComments[0].Body, "<!-- ado-comment-id: 1001 -->")
+		assert.NotContains(t, githubComments[0].Body, "Comment #1")
+	})
+
 	t.Run("handles empty comments", func(t *testing.T) {
 		cfg := &config.MigrationConfig{
 			FieldMapping: config.FieldMapping{
@@ -512,6 +1089,63 @@ func TestMapComments(t *testing.T) {
 	})
 }
 
+func TestDateFormatter(t *testing.T) {
+	date := time.Date(2024, 1, 15, 9, 5, 7, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "default",
+			format:   "",
+			expected: "2024-01-15 09:05:07 UTC",
+		},
+		{
+			name:     "go layout",
+			format:   "02/01/2006",
+			expected: "15/01/2024",
+		},
+		{
+			name:     "strftime european",
+			format:   "%d/%m/%Y %H:%M",
+			expected: "15/01/2024 09:05",
+		},
+		{
+			name:     "strftime iso",
+			format:   "%Y-%m-%dT%H:%M:%S%z",
+			expected: "2024-01-15T09:05:07+0000",
+		},
+		{
+			name:     "strftime names and literal percent",
+			format:   "%A %d %B %Y (100%%)",
+			expected: "Monday 15 January 2024 (100%)",
+		},
+		{
+			name:     "unknown directive kept",
+			format:   "%Y %Q",
+			expected: "2024 %Q",
+		},
+		{
+			name:     "literal layout words kept",
+			format:   "%d Monday",
+			expected: "15 Monday",
+		},
+		{
+			name:     "literal layout digits kept",
+			format:   "Week 1 of %Y, %H:%M PM",
+			expected: "Week 1 of 2024, 09:05 PM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newDateFormatter(tt.format).Format(date))
+		})
+	}
+}
+
 func TestCleanHtmlContent(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	cfg := &config.MigrationConfig{
@@ -619,6 +1253,85 @@ func TestDeduplicateLabels(t *testing.T) {
 	}
 }
 
+func TestMapTitle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	newWorkItem := func(workItemType string) *models.WorkItem {
+		return &models.WorkItem{
+			ID: 1,
+			Fields: map[string]interface{}{
+				"System.Title":        "Login fails",
+				"System.WorkItemType": workItemType,
+			},
+		}
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		mapper := NewMapper(&config.MigrationConfig{}, logger)
+		assert.Equal(t, "Login fails", mapper.mapTitle(newWorkItem("Bug")))
+	})
+
+	t.Run("built-in and configured emoji", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				IncludeTypeEmoji: true,
+				TypeEmoji: map[string]string{
+					"Task":    "✅",
+					"Feature": "🚀",
+					"Issue":   "",
+				},
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		assert.Equal(t, "🐛 Login fails", mapper.mapTitle(newWorkItem("Bug")))
+		assert.Equal(t, "✅ Login fails", mapper.mapTitle(newWorkItem("Task")))
+		assert.Equal(t, "🚀 Login fails", mapper.mapTitle(newWorkItem("Feature")))
+		assert.Equal(t, "Login fails", mapper.mapTitle(newWorkItem("Issue")))
+		assert.Equal(t, "Login fails", mapper.mapTitle(newWorkItem("Epic")))
+	})
+}
+
+func TestRequiredFields(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	t.Run("minimal config", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				TimeZone: "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		fields := mapper.RequiredFields()
+		assert.Contains(t, fields, "System.Title")
+		assert.Contains(t, fields, "System.Description")
+		assert.Contains(t, fields, "System.State")
+		assert.NotContains(t, fields, "Microsoft.VSTS.Common.Priority")
+		assert.NotContains(t, fields, "Microsoft.VSTS.Common.Severity")
+		assert.NotContains(t, fields, "System.AreaPath")
+	})
+
+	t.Run("optional labels add their fields", func(t *testing.T) {
+		cfg := &config.MigrationConfig{
+			FieldMapping: config.FieldMapping{
+				PriorityMapping: map[string][]string{
+					"1": {"priority:critical"},
+				},
+				IncludeSeverityLabel: true,
+				IncludeAreaPathLabel: true,
+				TimeZone:             "UTC",
+			},
+		}
+		mapper := NewMapper(cfg, logger)
+
+		fields := mapper.RequiredFields()
+		assert.Contains(t, fields, "Microsoft.VSTS.Common.Priority")
+		assert.Contains(t, fields, "Microsoft.VSTS.Common.Severity")
+		assert.Contains(t, fields, "System.AreaPath")
+	})
+}
+
 // Test integration scenarios
 func TestMapperIntegration(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -688,3 +1401,125 @@ func TestMapperIntegration(t *testing.T) {
 		assert.Equal(t, "Bug", issue.Metadata["original_type"])
 	})
 }
+
+func TestMapTargetDetails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mapper := NewMapper(&config.MigrationConfig{FieldMapping: config.FieldMapping{TimeZone: "UTC"}}, logger)
+
+	t.Run("planned story with parent", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 500,
+			Fields: map[string]interface{}{
+				"System.Title":                          "Checkout flow",
+				"System.WorkItemType":                   "User Story",
+				"System.IterationPath":                  "Project\\Release 1\\Sprint 3",
+				"Microsoft.VSTS.Scheduling.StoryPoints": 5.0,
+			},
+			Relations: []models.WorkItemRelation{
+				{Rel: models.RelationParent, URL: "https://dev.azure.com/org/_apis/wit/workItems/400"},
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Sprint 3", issue.MilestoneTitle)
+		assert.Equal(t, "Feature", issue.IssueType)
+		assert.Equal(t, 400, issue.SubIssueParent)
+		assert.Equal(t, map[string]string{
+			"Iteration":    "Release 1\\Sprint 3",
+			"Story Points": "5",
+		}, issue.ProjectFields)
+	})
+
+	t.Run("unplanned item without parent", func(t *testing.T) {
+		workItem := &models.WorkItem{
+			ID: 501,
+			Fields: map[string]interface{}{
+				"System.Title":         "Unplanned",
+				"System.WorkItemType":  "Issue",
+				"System.IterationPath": "Project",
+			},
+		}
+
+		issue, err := mapper.MapWorkItemToIssue(workItem)
+
+		require.NoError(t, err)
+		assert.Empty(t, issue.MilestoneTitle)
+		assert.Empty(t, issue.IssueType)
+		assert.Zero(t, issue.SubIssueParent)
+		assert.Nil(t, issue.ProjectFields)
+	})
+}
+
+func TestMappingTrace(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	cfg := &config.MigrationConfig{
+		FieldMapping: config.FieldMapping{
+			TimeZone:     "UTC",
+			StateMapping: map[string]string{"Done": "closed"},
+			TypeMapping:  map[string][]string{"bug": {"bug"}},
+			LabelAliases: map[string]string{"bug": "type: bug"},
+		},
+		UserMapping: map[string]string{"jane@example.com": "janesmith"},
+	}
+	mapper := NewMapper(cfg, logger)
+
+	workItem := &models.WorkItem{
+		ID: 600,
+		Fields: map[string]interface{}{
+			"System.Title":        "Traced",
+			"System.WorkItemType": "Bug",
+			"System.State":        "Done",
+			"System.Tags":         "ui",
+			"System.AssignedTo": map[string]interface{}{
+				"displayName": "Jane Smith",
+				"uniqueName":  "jane@example.com",
+			},
+		},
+	}
+
+	issue, err := mapper.MapWorkItemToIssue(workItem)
+
+	require.NoError(t, err)
+	trace, ok := issue.Metadata["mapping_trace"].([]string)
+	require.True(t, ok)
+	assert.Equal(t, []string{
+		`state closed: state_mapping["Done"]`,
+		`labels [bug]: type_mapping["bug"]`,
+		`label ui: tag "ui"`,
+		`label bug replaced by type: bug: label_aliases`,
+		`assignee janesmith: user_mapping["jane@example.com"]`,
+	}, trace)
+}
+
+func TestLogDebugFields(t *testing.T) {
+	var output strings.Builder
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cfg := &config.MigrationConfig{
+		FieldMapping: config.FieldMapping{
+			TimeZone:    "UTC",
+			DebugFields: []string{"Custom.Impact", "Custom.Missing"},
+			FieldApplicability: map[string][]string{
+				"Custom.Impact": {"Incident"},
+			},
+		},
+	}
+	mapper := NewMapper(cfg, logger)
+
+	_, err := mapper.MapWorkItemToIssue(&models.WorkItem{
+		ID: 700,
+		Fields: map[string]interface{}{
+			"System.Title":        "Debugged",
+			"System.WorkItemType": "Bug",
+			"Custom.Impact":       "High",
+		},
+	})
+
+	require.NoError(t, err)
+	logged := output.String()
+	// Raw values are logged even when field_applicability excludes the field from mapping
+	assert.Contains(t, logged, "field=Custom.Impact present=true type=string value=High")
+	assert.Contains(t, logged, "field=Custom.Missing present=false type=<nil>")
+	assert.Contains(t, mapper.RequiredFields(), "Custom.Missing")
+}
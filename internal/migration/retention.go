@@ -0,0 +1,108 @@
+package migration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/archive"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// retentionFields are read to tell when a closed work item was closed
+var retentionFields = []string{"Microsoft.VSTS.Common.ClosedDate", "System.ChangedDate"}
+
+// applyRetention records the work items excluded by skip_closed_before and returns the rest.
+// Excluded items are reported with their own status so they aren't mistaken for skips.
+// When retention_archive is set they are written there instead, so nothing is lost.
+func (e *Engine) applyRetention(workItems []*models.WorkItem) ([]*models.WorkItem, error) {
+	cutoff := e.config.SkipClosedBefore
+	if cutoff.IsZero() {
+		return workItems, nil
+	}
+
+	kept := make([]*models.WorkItem, 0, len(workItems))
+	excluded := []*models.WorkItem{}
+	closedDates := map[int]time.Time{}
+	for _, workItem := range workItems {
+		closedAt, old := e.closedBefore(workItem, cutoff)
+		if !old {
+			kept = append(kept, workItem)
+			continue
+		}
+
+		excluded = append(excluded, workItem)
+		closedDates[workItem.ID] = closedAt
+	}
+
+	if len(excluded) == 0 {
+		return kept, nil
+	}
+
+	note := ""
+	if e.config.RetentionArchive != "" {
+		if e.config.DryRun {
+			note = ", would be archived to " + e.config.RetentionArchive
+		} else {
+			if err := e.archiveExcluded(excluded); err != nil {
+				return nil, err
+			}
+			note = ", archived to " + e.config.RetentionArchive
+		}
+	}
+
+	for _, workItem := range excluded {
+		closedAt := closedDates[workItem.ID]
+		e.logger.Debug("Work item closed before skip_closed_before, excluding", "id", workItem.ID, "closed", closedAt)
+		e.results.outcome(workItem, nil, 0, "excluded",
+			fmt.Sprintf("Closed on %s, before skip_closed_before %s%s", closedAt.Format(time.DateOnly), cutoff.Format(time.DateOnly), note), "")
+	}
+
+	e.logger.Info("Excluded work items closed before skip_closed_before", "count", len(excluded), "before", cutoff.Format(time.DateOnly))
+
+	return kept, nil
+}
+
+// archiveExcluded appends the excluded work items, comments included, to the retention archive.
+// Items already archived by an earlier run are not written again.
+func (e *Engine) archiveExcluded(workItems []*models.WorkItem) error {
+	writer, archived, err := archive.Resume(e.config.RetentionArchive, e.datasetContents())
+	if err != nil {
+		return fmt.Errorf("failed to open retention archive: %w", err)
+	}
+	defer writer.Close()
+
+	written := 0
+	for _, workItem := range workItems {
+		if archived[workItem.ID] {
+			continue
+		}
+
+		if err := writer.Write(workItem); err != nil {
+			return fmt.Errorf("failed to archive work item %d: %w", workItem.ID, err)
+		}
+		written++
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close retention archive: %w", err)
+	}
+
+	e.logger.Info("Archived excluded work items", "path", e.config.RetentionArchive, "written", written, "already_archived", len(workItems)-written)
+
+	return nil
+}
+
+// closedBefore reports whether workItem maps to a closed issue and was closed before cutoff.
+// Items without a known close date are kept.
+func (e *Engine) closedBefore(workItem *models.WorkItem, cutoff time.Time) (time.Time, bool) {
+	if e.mapper.mapState(workItem.GetState(), nil) != "closed" {
+		return time.Time{}, false
+	}
+
+	closedAt := workItem.GetClosedDate()
+	if closedAt == nil {
+		return time.Time{}, false
+	}
+
+	return *closedAt, closedAt.Before(cutoff)
+}
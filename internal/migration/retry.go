@@ -0,0 +1,70 @@
+package migration
+
+import "time"
+
+// RetryEntry tracks a work item that failed to migrate so later runs can retry it
+type RetryEntry struct {
+	WorkItemID    int       `json:"work_item_id"`
+	Attempts      int       `json:"attempts"`
+	ErrorCategory string    `json:"error_category"`
+	LastError     string    `json:"last_error"`
+	LastAttempt   time.Time `json:"last_attempt"`
+	Escalated     bool      `json:"escalated,omitempty"` // Failed max_retry_attempts times, needs manual attention
+}
+
+// recordRetry counts a failed attempt for workItemID and escalates the entry once it
+// reaches maxAttempts. A maxAttempts of zero or less never escalates.
+func (c *MigrationCheckpoint) recordRetry(workItemID int, category, message string, maxAttempts int) *RetryEntry {
+	var entry *RetryEntry
+	for i := range c.RetryQueue {
+		if c.RetryQueue[i].WorkItemID == workItemID {
+			entry = &c.RetryQueue[i]
+			break
+		}
+	}
+	if entry == nil {
+		c.RetryQueue = append(c.RetryQueue, RetryEntry{WorkItemID: workItemID})
+		entry = &c.RetryQueue[len(c.RetryQueue)-1]
+	}
+
+	entry.Attempts++
+	entry.ErrorCategory = category
+	entry.LastError = message
+	entry.LastAttempt = time.Now()
+	if maxAttempts > 0 && entry.Attempts >= maxAttempts {
+		entry.Escalated = true
+	}
+
+	return entry
+}
+
+// clearRetry removes workItemID from the retry queue and the failed items
+func (c *MigrationCheckpoint) clearRetry(workItemID int) {
+	queue := c.RetryQueue[:0]
+	for _, entry := range c.RetryQueue {
+		if entry.WorkItemID != workItemID {
+			queue = append(queue, entry)
+		}
+	}
+	c.RetryQueue = queue
+
+	failed := c.FailedItems[:0]
+	for _, id := range c.FailedItems {
+		if id != workItemID {
+			failed = append(failed, id)
+		}
+	}
+	c.FailedItems = failed
+}
+
+// pendingRetries returns the IDs in the retry queue that have not been escalated
+func (c *MigrationCheckpoint) pendingRetries() map[int]bool {
+	pending := make(map[int]bool, len(c.RetryQueue))
+	for _, entry := range c.RetryQueue {
+		if !entry.Escalated {
+			pending[entry.WorkItemID] = true
+		}
+	}
+
+	return pending
+}
@@ -0,0 +1,48 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryQueue(t *testing.T) {
+	t.Run("escalates after max attempts", func(t *testing.T) {
+		checkpoint := &MigrationCheckpoint{}
+
+		entry := checkpoint.recordRetry(1, ErrorCategoryNetwork, "timeout", 2)
+		assert.Equal(t, 1, entry.Attempts)
+		assert.False(t, entry.Escalated)
+		assert.Equal(t, map[int]bool{1: true}, checkpoint.pendingRetries())
+
+		entry = checkpoint.recordRetry(1, ErrorCategoryRateLimit, "rate limited", 2)
+		assert.Equal(t, 2, entry.Attempts)
+		assert.True(t, entry.Escalated)
+		assert.Equal(t, ErrorCategoryRateLimit, entry.ErrorCategory)
+		assert.Empty(t, checkpoint.pendingRetries())
+		require.Len(t, checkpoint.RetryQueue, 1)
+	})
+
+	t.Run("zero max attempts never escalates", func(t *testing.T) {
+		checkpoint := &MigrationCheckpoint{}
+
+		for i := 0; i < 5; i++ {
+			checkpoint.recordRetry(1, ErrorCategoryOther, "boom", 0)
+		}
+
+		assert.False(t, checkpoint.RetryQueue[0].Escalated)
+		assert.Equal(t, 5, checkpoint.RetryQueue[0].Attempts)
+	})
+
+	t.Run("clear removes queue entry and failed item", func(t *testing.T) {
+		checkpoint := &MigrationCheckpoint{FailedItems: []int{1, 2}}
+		checkpoint.recordRetry(1, ErrorCategoryOther, "boom", 3)
+		checkpoint.recordRetry(2, ErrorCategoryOther, "boom", 3)
+
+		checkpoint.clearRetry(1)
+
+		assert.Equal(t, []int{2}, checkpoint.FailedItems)
+		assert.Equal(t, map[int]bool{2: true}, checkpoint.pendingRetries())
+	})
+}
@@ -0,0 +1,458 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/archive"
+	"github.com/jlucaspains/adowi2gh/internal/config"
+	"github.com/jlucaspains/adowi2gh/internal/lock"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// slowTracker takes delay to create each issue, giving up when the context is done
+type slowTracker struct {
+	*fakeTracker
+	delay time.Duration
+}
+
+func (s *slowTracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to create issue: %w", ctx.Err())
+	}
+
+	return s.fakeTracker.CreateIssue(ctx, issue)
+}
+
+// takeoverTracker lets another run take over the lease while the first issue is created
+// and waits until the background renewal notices
+type takeoverTracker struct {
+	*fakeTracker
+	lockPath, checkpointPath string
+	once                     sync.Once
+}
+
+func (s *takeoverTracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	s.once.Do(func() {
+		data, _ := json.Marshal(lock.Lease{Owner: "other:1", AcquiredAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)})
+		_ = os.WriteFile(s.lockPath, data, 0600)
+		_ = os.WriteFile(s.checkpointPath, []byte(`{"processed_items": [101]}`), 0600)
+		time.Sleep(150 * time.Millisecond)
+	})
+
+	return s.fakeTracker.CreateIssue(ctx, issue)
+}
+
+// interruptingTracker cancels the run while creating the issue for interruptID, like
+// SIGINT arriving mid-request
+type interruptingTracker struct {
+	*fakeTracker
+	interruptID int
+	cancel      context.CancelFunc
+}
+
+func (s *interruptingTracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	if issue.SourceWIID == s.interruptID {
+		s.cancel()
+		return nil, fmt.Errorf("failed to create issue: %w", ctx.Err())
+	}
+
+	return s.fakeTracker.CreateIssue(ctx, issue)
+}
+
+// throttledSource reports an Azure DevOps backoff of wait for every streamed batch
+type throttledSource struct {
+	*fakeSource
+	wait time.Duration
+}
+
+func (s *throttledSource) StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
+	return s.fakeSource.StreamWorkItems(ctx, workItemIds, func(batch []*models.WorkItem) error {
+		s.observe(s.wait)
+		return handle(batch)
+	})
+}
+
+// secondaryLimitTracker reports a GitHub secondary rate limit wait of wait for every created issue
+type secondaryLimitTracker struct {
+	*fakeTracker
+	wait time.Duration
+}
+
+func (s *secondaryLimitTracker) CreateIssue(ctx context.Context, issue *models.GitHubIssue) (*models.GitHubIssue, error) {
+	s.observe(s.wait)
+	return s.fakeTracker.CreateIssue(ctx, issue)
+}
+
+// offlineSource fails every query, the publish phase must not go back to Azure DevOps
+type offlineSource struct {
+	*fakeSource
+}
+
+func (offlineSource) QueryWorkItemIDs(ctx context.Context) ([]int, error) {
+	return nil, errors.New("Azure DevOps queried while publishing")
+}
+
+func (offlineSource) StreamWorkItems(ctx context.Context, workItemIds []int, handle func([]*models.WorkItem) error) error {
+	return errors.New("Azure DevOps queried while publishing")
+}
+
+func (offlineSource) GetWorkItemComments(ctx context.Context, workItemID int) ([]models.WorkItemComment, error) {
+	return nil, errors.New("Azure DevOps queried while publishing")
+}
+
+// fetchDataset runs a fetch-only migration of the test work items with cfg
+func fetchDataset(t *testing.T, cfg *config.Config) {
+	t.Helper()
+
+	fetchCfg := *cfg
+	fetchCfg.Migration.FetchOnly = true
+	tracker := newFakeTracker()
+
+	report, err := newTestEngine(&fetchCfg, &fakeSource{}, tracker).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 6, report.TotalWorkItems)
+	require.Empty(t, tracker.Issues())
+}
+
+func readDataset(t *testing.T, path string) (*archive.Contents, []*models.WorkItem) {
+	t.Helper()
+
+	reader, err := archive.Open(path)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var workItems []*models.WorkItem
+	for {
+		workItem, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		workItems = append(workItems, workItem)
+	}
+
+	return reader.Contents(), workItems
+}
+
+func TestRun_FetchOnly(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.IncludeComments = true
+
+	fetchDataset(t, cfg)
+
+	contents, workItems := readDataset(t, cfg.Migration.DatasetPath)
+	require.NotNil(t, contents)
+	assert.True(t, contents.Comments)
+	assert.Contains(t, contents.Fields, "System.Title")
+	require.Len(t, workItems, 6)
+	assert.Len(t, workItems[0].Comments, 2)
+
+	_, err := os.Stat(cfg.Migration.CheckpointPath + ".lock")
+	assert.True(t, os.IsNotExist(err), "fetch-only runs don't take the run lock")
+}
+
+func TestRun_PublishOnly(t *testing.T) {
+	t.Run("publishes the dataset without querying Azure DevOps", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Migration.IncludeComments = true
+		fetchDataset(t, cfg)
+
+		cfg.Migration.PublishOnly = true
+		tracker := newFakeTracker()
+		report, err := newTestEngine(cfg, offlineSource{&fakeSource{}}, tracker).Run(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 6, report.TotalWorkItems)
+		assert.Equal(t, 5, report.SuccessfulCount)
+		issues := tracker.Issues()
+		require.Len(t, issues, 5)
+		assert.Len(t, issues[0].Comments, 2)
+	})
+
+	t.Run("rejects a dataset missing fields the mapping needs", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		fetchDataset(t, cfg)
+
+		cfg.Migration.PublishOnly = true
+		cfg.Migration.FieldMapping.IncludeSeverityLabel = true
+		tracker := newFakeTracker()
+		_, err := newTestEngine(cfg, offlineSource{&fakeSource{}}, tracker).Run(context.Background())
+		require.ErrorIs(t, err, ErrStaleDataset)
+		assert.ErrorContains(t, err, "Microsoft.VSTS.Common.Severity")
+		assert.Empty(t, tracker.Issues())
+	})
+
+	t.Run("rejects a dataset fetched without comments", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		fetchDataset(t, cfg)
+
+		cfg.Migration.PublishOnly = true
+		cfg.Migration.IncludeComments = true
+		_, err := newTestEngine(cfg, offlineSource{&fakeSource{}}, newFakeTracker()).Run(context.Background())
+		require.ErrorIs(t, err, ErrStaleDataset)
+	})
+}
+
+func TestRun_DryRunKeepsDataset(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.IncludeComments = true
+
+	t.Run("no dataset is written", func(t *testing.T) {
+		dryRun := *cfg
+		dryRun.Migration.DryRun = true
+
+		report, err := newTestEngine(&dryRun, &fakeSource{}, newFakeTracker()).Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 6, report.TotalWorkItems)
+
+		_, err = os.Stat(cfg.Migration.DatasetPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("an existing dataset is left unchanged", func(t *testing.T) {
+		fetchDataset(t, cfg)
+		before, err := os.ReadFile(cfg.Migration.DatasetPath)
+		require.NoError(t, err)
+
+		for _, fetchOnly := range []bool{false, true} {
+			dryRun := *cfg
+			dryRun.Migration.DryRun = true
+			dryRun.Migration.FetchOnly = fetchOnly
+			_, err = newTestEngine(&dryRun, &fakeSource{}, newFakeTracker()).Run(context.Background())
+			require.NoError(t, err)
+		}
+
+		after, err := os.ReadFile(cfg.Migration.DatasetPath)
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(before, after))
+	})
+}
+
+func TestRun_TimeBudget(t *testing.T) {
+	t.Run("max run duration stops dispatching", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Migration.BatchSize = 1
+		cfg.Migration.MaxRunDuration = 100 * time.Millisecond
+		tracker := &slowTracker{fakeTracker: newFakeTracker(), delay: 40 * time.Millisecond}
+
+		report, err := newTestEngine(cfg, &fakeSource{}, tracker).Run(context.Background())
+		require.ErrorIs(t, err, ErrTimeBudgetExceeded)
+		require.NotNil(t, report)
+
+		processed := report.SuccessfulCount + report.FailedCount
+		assert.Positive(t, processed)
+		assert.Less(t, processed, 6)
+		assert.Len(t, tracker.Issues(), report.SuccessfulCount)
+
+		checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+		require.NoError(t, err)
+		assert.Len(t, checkpoint.ProcessedItems, report.SuccessfulCount)
+		assert.Len(t, checkpoint.FailedItems, report.FailedCount)
+	})
+
+	t.Run("max item duration stops dispatching", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Migration.MaxItemDuration = 20 * time.Millisecond
+		tracker := &slowTracker{fakeTracker: newFakeTracker(), delay: time.Second}
+
+		report, err := newTestEngine(cfg, &fakeSource{}, tracker).Run(context.Background())
+		require.ErrorIs(t, err, ErrTimeBudgetExceeded)
+		require.NotNil(t, report)
+
+		assert.Empty(t, tracker.Issues())
+		assert.Equal(t, 1, report.FailedCount)
+		assert.Zero(t, report.SuccessfulCount)
+
+		checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+		require.NoError(t, err)
+		assert.Equal(t, []int{101}, checkpoint.FailedItems)
+		assert.Empty(t, checkpoint.ProcessedItems)
+	})
+}
+
+func TestRun_RetentionArchive(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.SkipClosedBefore = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg.Migration.RetentionArchive = filepath.Join(t.TempDir(), "retained.ndjson.gz")
+
+	report, err := newTestEngine(cfg, &fakeSource{}, newFakeTracker()).Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, report.ExcludedCount)
+
+	contents, archived := readDataset(t, cfg.Migration.RetentionArchive)
+	require.NotNil(t, contents)
+	assert.Empty(t, contents.Fields, "excluded work items are archived with all fields")
+	assert.True(t, contents.Comments)
+
+	require.Len(t, archived, 2)
+	closed := archived[1]
+	assert.Equal(t, 106, closed.ID)
+	// Neither the area path nor the priority is read by the default mapping
+	assert.Contains(t, closed.Fields, "System.AreaPath")
+	assert.Contains(t, closed.Fields, "Microsoft.VSTS.Common.Priority")
+	// Comments are archived even though include_comments is off
+	assert.Len(t, closed.Comments, 1)
+}
+
+func TestRun_RetentionArchiveNeedsFullDataset(t *testing.T) {
+	cfg := newTestConfig(t)
+	fetchDataset(t, cfg)
+
+	cfg.Migration.PublishOnly = true
+	cfg.Migration.SkipClosedBefore = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg.Migration.RetentionArchive = filepath.Join(t.TempDir(), "retained.ndjson.gz")
+
+	_, err := newTestEngine(cfg, offlineSource{&fakeSource{}}, newFakeTracker()).Run(context.Background())
+	require.ErrorIs(t, err, ErrStaleDataset)
+	_, err = os.Stat(cfg.Migration.RetentionArchive)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRun_LockLost(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.BatchSize = 1
+	cfg.Migration.LockTTL = 90 * time.Millisecond
+	tracker := &takeoverTracker{
+		fakeTracker:    newFakeTracker(),
+		lockPath:       cfg.Migration.CheckpointPath + ".lock",
+		checkpointPath: cfg.Migration.CheckpointPath,
+	}
+
+	report, err := newTestEngine(cfg, &fakeSource{}, tracker).Run(context.Background())
+	require.ErrorIs(t, err, lock.ErrLocked)
+	require.NotNil(t, report, "the items migrated before the lease was lost are reported")
+	assert.Equal(t, 1, report.SuccessfulCount)
+	assert.Len(t, tracker.Issues(), 1, "no batch is started after the lease was lost")
+
+	// The checkpoint belongs to the run that holds the lease now
+	checkpoint, err := os.ReadFile(cfg.Migration.CheckpointPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"processed_items": [101]}`, string(checkpoint))
+
+	lease, err := lock.Read(cfg.Migration.CheckpointPath + ".lock")
+	require.NoError(t, err)
+	assert.Equal(t, "other:1", lease.Owner)
+}
+
+func TestRun_Resume(t *testing.T) {
+	t.Run("starts from the beginning without a checkpoint", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		cfg.Migration.ResumeFromCheckpoint = true
+
+		report, err := newTestEngine(cfg, &fakeSource{}, newFakeTracker()).Run(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.SuccessfulCount)
+	})
+
+	// resume runs against a checkpoint holding content, which must be left for a version
+	// that can read it
+	resume := func(t *testing.T, content string) error {
+		cfg := newTestConfig(t)
+		cfg.Migration.ResumeFromCheckpoint = true
+		require.NoError(t, os.WriteFile(cfg.Migration.CheckpointPath, []byte(content), 0600))
+		tracker := newFakeTracker()
+
+		_, err := newTestEngine(cfg, &fakeSource{}, tracker).Run(context.Background())
+		assert.Empty(t, tracker.Issues())
+
+		checkpoint, readErr := os.ReadFile(cfg.Migration.CheckpointPath)
+		require.NoError(t, readErr)
+		assert.Equal(t, content, string(checkpoint))
+
+		return err
+	}
+
+	t.Run("refuses a checkpoint written by a newer version", func(t *testing.T) {
+		err := resume(t, `{"schema_version": 99, "processed_items": [101]}`)
+		assert.ErrorIs(t, err, ErrUnsupportedCheckpoint)
+	})
+
+	t.Run("refuses a checkpoint that can't be parsed", func(t *testing.T) {
+		err := resume(t, `{"processed_items": [101`)
+		assert.ErrorContains(t, err, "failed to load checkpoint")
+	})
+}
+
+func TestRun_Interrupted(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.BatchSize = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracker := &interruptingTracker{fakeTracker: newFakeTracker(), interruptID: 102, cancel: cancel}
+
+	report, err := newTestEngine(cfg, &fakeSource{}, tracker).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, report)
+
+	// The interrupted item is not a failure and no further batch is started
+	assert.Equal(t, 1, report.SuccessfulCount)
+	assert.Zero(t, report.FailedCount)
+	assert.Empty(t, report.Errors)
+	assert.Len(t, tracker.Issues(), 1)
+
+	checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+	require.NoError(t, err)
+	assert.Equal(t, []int{101}, checkpoint.ProcessedItems)
+	assert.Empty(t, checkpoint.FailedItems)
+	assert.Empty(t, checkpoint.RetryQueue)
+}
+
+func TestRun_Stats(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Migration.BatchSize = 2
+	setBatchPause(t, time.Millisecond)
+	tracker := &secondaryLimitTracker{fakeTracker: newFakeTracker(), wait: time.Second}
+	source := &throttledSource{fakeSource: &fakeSource{}, wait: 2 * time.Second}
+
+	_, err := newTestEngine(cfg, source, tracker).Run(context.Background())
+	require.NoError(t, err)
+
+	checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+	require.NoError(t, err)
+	stats := checkpoint.Stats
+	assert.Equal(t, 6, stats.TotalWorkItems)
+	assert.Equal(t, 6, stats.RunWorkItems)
+	assert.Equal(t, 6, stats.Attempted)
+	// Three throttled batches and six issue creations, the pause between batches is not a rate limit
+	assert.Equal(t, 9, stats.RateLimitWaits)
+	assert.Equal(t, 12*time.Second, stats.RateLimitWait)
+
+	t.Run("a resumed run leaves migrated items out of the average", func(t *testing.T) {
+		resumed := *cfg
+		resumed.Migration.ResumeFromCheckpoint = true
+		_, err := newTestEngine(&resumed, &fakeSource{}, newFakeTracker()).Run(context.Background())
+		require.NoError(t, err)
+
+		checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+		require.NoError(t, err)
+		assert.Equal(t, 5, checkpoint.Stats.Skipped)
+		assert.Equal(t, 7, checkpoint.Stats.Attempted, "only the failed item was worked on again")
+	})
+
+	t.Run("a retry run keeps the migration scope", func(t *testing.T) {
+		retry := *cfg
+		retry.Migration.RetryFailed = true
+		_, err := newTestEngine(&retry, &fakeSource{}, newFakeTracker()).Run(context.Background())
+		require.NoError(t, err)
+
+		checkpoint, err := LoadCheckpoint(cfg.Migration.CheckpointPath)
+		require.NoError(t, err)
+		assert.Equal(t, 6, checkpoint.Stats.TotalWorkItems)
+		assert.Equal(t, 1, checkpoint.Stats.RunWorkItems)
+	})
+}
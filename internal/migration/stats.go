@@ -0,0 +1,76 @@
+package migration
+
+import "time"
+
+// CheckpointStats are running totals kept in the checkpoint so progress can be shown
+// without recomputing it from the mappings. They accumulate across resumed runs.
+type CheckpointStats struct {
+	TotalWorkItems int           `json:"total_work_items"` // Work items in scope for the migration, retry runs leave it unchanged
+	RunWorkItems   int           `json:"run_work_items"`   // Work items in scope for the latest run
+	Successful     int           `json:"successful"`
+	Failed         int           `json:"failed"` // Failed attempts, an item retried twice counts twice
+	Skipped        int           `json:"skipped"`
+	Attempted      int           `json:"attempted"` // Work items worked on, leaving out those skipped as already migrated
+	Runs           int           `json:"runs"`
+	Batches        int           `json:"batches"`
+	ItemDuration   time.Duration `json:"item_duration_ns"` // Time spent migrating work items, summed across workers
+	RateLimitWaits int           `json:"rate_limit_waits"`
+	RateLimitWait  time.Duration `json:"rate_limit_wait_ns"`
+}
+
+// Processed is the number of work item attempts recorded
+func (s CheckpointStats) Processed() int {
+	return s.Successful + s.Failed + s.Skipped
+}
+
+// AverageItemDuration is the mean time spent per attempted work item. Items skipped as
+// already migrated take no time and would make every resumed run lower the estimate.
+func (s CheckpointStats) AverageItemDuration() time.Duration {
+	if s.Attempted == 0 {
+		return 0
+	}
+
+	return s.ItemDuration / time.Duration(s.Attempted)
+}
+
+// startRun records the start of a migration run over total work items
+func (c *collector) startRun(total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoint.Stats.Runs++
+	c.checkpoint.Stats.RunWorkItems = total
+}
+
+// migrationScope records the number of work items the whole migration covers
+func (c *collector) migrationScope(total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoint.Stats.TotalWorkItems = total
+}
+
+// itemDuration adds the time spent on a single work item
+func (c *collector) itemDuration(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoint.Stats.ItemDuration += d
+}
+
+// batchFinished counts a completed batch
+func (c *collector) batchFinished() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoint.Stats.Batches++
+}
+
+// rateLimitWait records time spent waiting to stay under rate limits
+func (c *collector) rateLimitWait(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkpoint.Stats.RateLimitWaits++
+	c.checkpoint.Stats.RateLimitWait += d
+}
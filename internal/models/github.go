@@ -19,36 +19,119 @@ type GitHubIssue struct {
 	Comments   []GitHubComment        `json:"comments,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	SourceWIID int                    `json:"source_wi_id"` // Original ADO work item ID
+
+	// Describe the target independently of how it is created. The GitHub client decides
+	// how to realize them depending on what the API and repository support.
+	MilestoneTitle string            `json:"milestone_title,omitempty"`
+	ProjectFields  map[string]string `json:"project_fields,omitempty"`   // GitHub Projects field name -> value
+	IssueType      string            `json:"issue_type,omitempty"`       // e.g. "Bug", "Feature", "Task"
+	SubIssueParent int               `json:"sub_issue_parent,omitempty"` // ADO ID of the parent work item
 }
 
 // GitHubComment represents a comment on a GitHub issue
 type GitHubComment struct {
-	Body string `json:"body"`
+	Body            string     `json:"body"`
+	SourceCommentID int        `json:"source_comment_id,omitempty"` // Original ADO comment ID
+	OriginalAuthor  string     `json:"original_author,omitempty"`   // GitHub login of the ADO author, when mapped
+	CreatedAt       *time.Time `json:"created_at,omitempty"`        // When the comment was made in ADO
 }
 
 // MigrationMapping represents the mapping between ADO work item and GitHub issue
 type MigrationMapping struct {
 	AdoWorkItemID   int       `json:"ado_work_item_id"`
 	AdoWorkItemType string    `json:"ado_work_item_type"`
+	AdoWorkItemURL  string    `json:"ado_work_item_url,omitempty"` // Work item page in ADO
 	GitHubIssueID   int       `json:"github_issue_id"`
 	GitHubIssueURL  string    `json:"github_issue_url"`
 	MigratedAt      time.Time `json:"migrated_at"`
-	Status          string    `json:"status"` // "success", "failed", "skipped"
+	Status          string    `json:"status"` // "success", "failed", "skipped", "excluded"
 	ErrorMessage    string    `json:"error_message,omitempty"`
+	ErrorCategory   string    `json:"error_category,omitempty"`
+	TargetState     string    `json:"target_state,omitempty"`
+	Labels          []string  `json:"labels,omitempty"`
+	Trace           []string  `json:"trace,omitempty"` // Rules behind each mapping decision, dry runs with trace_mapping only
+
+	RejectedAssignees []string `json:"rejected_assignees,omitempty"` // Mapped assignees that can't be assigned in the repository, dry runs only
 }
 
 // MigrationReport represents a summary of the migration process
 type MigrationReport struct {
+	RunLabel        string             `json:"run_label,omitempty"`
+	DryRun          bool               `json:"dry_run,omitempty"`
 	StartTime       time.Time          `json:"start_time"`
 	EndTime         *time.Time         `json:"end_time,omitempty"`
 	TotalWorkItems  int                `json:"total_work_items"`
 	SuccessfulCount int                `json:"successful_count"`
 	FailedCount     int                `json:"failed_count"`
 	SkippedCount    int                `json:"skipped_count"`
+	ExcludedCount   int                `json:"excluded_count,omitempty"` // Left out by retention filters such as skip_closed_before
+	Breakdown       *ReportBreakdown   `json:"breakdown,omitempty"`
+	GitHubRequests  map[string]int     `json:"github_requests,omitempty"` // requests sent per category (rest, graphql, search)
 	Mappings        []MigrationMapping `json:"mappings"`
 	Errors          []string           `json:"errors,omitempty"`
 }
 
+// StatusCounts tallies mappings by outcome
+type StatusCounts struct {
+	Successful int `json:"successful"`
+	Failed     int `json:"failed"`
+	Skipped    int `json:"skipped"`
+	Excluded   int `json:"excluded,omitempty"`
+}
+
+// ReportBreakdown aggregates the mappings of a run. States and labels only
+// count successfully migrated issues.
+type ReportBreakdown struct {
+	ByType  map[string]StatusCounts `json:"by_type"`
+	ByState map[string]int          `json:"by_state"`
+	ByLabel map[string]int          `json:"by_label"`
+
+	ByRejectedAssignee map[string]int `json:"by_rejected_assignee,omitempty"` // Work items per assignee the repository would reject
+}
+
+// ComputeBreakdown aggregates the report mappings into Breakdown
+func (r *MigrationReport) ComputeBreakdown() {
+	breakdown := &ReportBreakdown{
+		ByType:  map[string]StatusCounts{},
+		ByState: map[string]int{},
+		ByLabel: map[string]int{},
+	}
+
+	for _, mapping := range r.Mappings {
+		for _, login := range mapping.RejectedAssignees {
+			if breakdown.ByRejectedAssignee == nil {
+				breakdown.ByRejectedAssignee = map[string]int{}
+			}
+			breakdown.ByRejectedAssignee[login]++
+		}
+
+		counts := breakdown.ByType[mapping.AdoWorkItemType]
+		switch mapping.Status {
+		case "success":
+			counts.Successful++
+		case "failed":
+			counts.Failed++
+		case "skipped":
+			counts.Skipped++
+		case "excluded":
+			counts.Excluded++
+		}
+		breakdown.ByType[mapping.AdoWorkItemType] = counts
+
+		if mapping.Status != "success" {
+			continue
+		}
+		if mapping.TargetState != "" {
+			breakdown.ByState[mapping.TargetState]++
+		}
+		for _, label := range mapping.Labels {
+			breakdown.ByLabel[label]++
+		}
+	}
+
+	r.Breakdown = breakdown
+}
+
 // MigrationStatus represents the current status of the migration
 type MigrationStatus struct {
 	IsRunning      bool      `json:"is_running"`
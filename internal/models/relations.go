@@ -0,0 +1,108 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Relation types used by the work item helpers
+const (
+	RelationParent    = "System.LinkTypes.Hierarchy-Reverse"
+	RelationChild     = "System.LinkTypes.Hierarchy-Forward"
+	RelationTestedBy  = "Microsoft.VSTS.Common.TestedBy-Forward"
+	RelationAttached  = "AttachedFile"
+	RelationHyperlink = "Hyperlink"
+)
+
+// WorkItemHyperlink is a hyperlink relation with its optional comment
+type WorkItemHyperlink struct {
+	URL     string `json:"url"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// WorkItemID returns the ID of the work item a relation points at. It is false for
+// relations to other resources such as attachments or hyperlinks.
+func (r WorkItemRelation) WorkItemID() (int, bool) {
+	const segment = "/workitems/"
+
+	index := strings.LastIndex(strings.ToLower(r.URL), segment)
+	if index < 0 {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(r.URL[index+len(segment):])
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// GetRelatedIDs returns the IDs of the work items linked with the given relation type, in link order
+func (wi *WorkItem) GetRelatedIDs(rel string) []int {
+	var ids []int
+	for _, relation := range wi.Relations {
+		if relation.Rel != rel {
+			continue
+		}
+		if id, ok := relation.WorkItemID(); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// GetParentID returns the ID of the parent work item, or 0 when there is none
+func (wi *WorkItem) GetParentID() int {
+	if ids := wi.GetRelatedIDs(RelationParent); len(ids) > 0 {
+		return ids[0]
+	}
+	return 0
+}
+
+// GetChildIDs returns the IDs of the child work items
+func (wi *WorkItem) GetChildIDs() []int {
+	return wi.GetRelatedIDs(RelationChild)
+}
+
+// GetAttachments returns the attached files described by the work item relations
+func (wi *WorkItem) GetAttachments() []WorkItemAttachment {
+	var attachments []WorkItemAttachment
+	for _, relation := range wi.Relations {
+		if relation.Rel != RelationAttached {
+			continue
+		}
+
+		id := relation.URL[strings.LastIndex(relation.URL, "/")+1:]
+		if query := strings.Index(id, "?"); query >= 0 {
+			id = id[:query]
+		}
+
+		attachments = append(attachments, WorkItemAttachment{
+			ID:   id,
+			Name: relation.GetAttributeString("name"),
+			URL:  relation.URL,
+			Size: relation.GetAttributeInt("resourceSize"),
+		})
+	}
+
+	return attachments
+}
+
+// GetHyperlinks returns the hyperlinks added to the work item
+func (wi *WorkItem) GetHyperlinks() []WorkItemHyperlink {
+	var links []WorkItemHyperlink
+	for _, relation := range wi.Relations {
+		if relation.Rel != RelationHyperlink {
+			continue
+		}
+
+		links = append(links, WorkItemHyperlink{
+			URL:     relation.URL,
+			Comment: relation.GetAttributeString("comment"),
+		})
+	}
+
+	return links
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkItem_RelationHelpers(t *testing.T) {
+	workItem := &WorkItem{
+		Relations: []WorkItemRelation{
+			{Rel: RelationParent, URL: "https://dev.azure.com/org/_apis/wit/workItems/10"},
+			{Rel: RelationChild, URL: "https://dev.azure.com/org/_apis/wit/workItems/21"},
+			{Rel: RelationChild, URL: "https://dev.azure.com/org/_apis/wit/workitems/22"},
+			{
+				Rel: RelationAttached,
+				URL: "https://dev.azure.com/org/_apis/wit/attachments/8a3c1f2e-0000-4000-8000-000000000001?fileName=log.txt",
+				Attributes: map[string]interface{}{
+					"name":         "log.txt",
+					"resourceSize": float64(512),
+				},
+			},
+			{
+				Rel:        RelationHyperlink,
+				URL:        "https://example.com/spec",
+				Attributes: map[string]interface{}{"comment": "Design spec"},
+			},
+			{Rel: "ArtifactLink", URL: "vstfs:///Git/Commit/abc"},
+		},
+	}
+
+	t.Run("parent", func(t *testing.T) {
+		assert.Equal(t, 10, workItem.GetParentID())
+		assert.Equal(t, 0, (&WorkItem{}).GetParentID())
+	})
+
+	t.Run("children", func(t *testing.T) {
+		assert.Equal(t, []int{21, 22}, workItem.GetChildIDs())
+	})
+
+	t.Run("attachments", func(t *testing.T) {
+		attachments := workItem.GetAttachments()
+		require.Len(t, attachments, 1)
+		assert.Equal(t, "8a3c1f2e-0000-4000-8000-000000000001", attachments[0].ID)
+		assert.Equal(t, "log.txt", attachments[0].Name)
+		assert.Equal(t, int64(512), attachments[0].Size)
+	})
+
+	t.Run("hyperlinks", func(t *testing.T) {
+		assert.Equal(t, []WorkItemHyperlink{{URL: "https://example.com/spec", Comment: "Design spec"}}, workItem.GetHyperlinks())
+	})
+
+	t.Run("non work item relations have no ID", func(t *testing.T) {
+		_, ok := workItem.Relations[5].WorkItemID()
+		assert.False(t, ok)
+	})
+}
@@ -23,6 +23,28 @@ type WorkItemRelation struct {
 	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
+// GetAttributeString returns a string relation attribute such as "name" or "comment"
+func (r WorkItemRelation) GetAttributeString(key string) string {
+	if value, ok := r.Attributes[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// GetAttributeInt returns a numeric relation attribute such as "resourceSize". JSON
+// decodes numbers as float64, so both forms are accepted.
+func (r WorkItemRelation) GetAttributeInt(key string) int64 {
+	switch value := r.Attributes[key].(type) {
+	case float64:
+		return int64(value)
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	}
+	return 0
+}
+
 // WorkItemComment represents a comment on a work item
 type WorkItemComment struct {
 	ID           int        `json:"id"`
@@ -50,6 +72,52 @@ type User struct {
 	UniqueName  string `json:"uniqueName"`
 }
 
+// Iteration represents a node of the project iteration tree
+type Iteration struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Path       string      `json:"path"` // As used in System.IterationPath, e.g. "Project\Release 1\Sprint 1"
+	StartDate  *time.Time  `json:"startDate,omitempty"`
+	FinishDate *time.Time  `json:"finishDate,omitempty"`
+	Children   []Iteration `json:"children,omitempty"`
+}
+
+// Find returns the iteration with the given path in this subtree, matching case-insensitively,
+// or nil when there is none
+func (it *Iteration) Find(path string) *Iteration {
+	if strings.EqualFold(it.Path, path) {
+		return it
+	}
+
+	for i := range it.Children {
+		if found := it.Children[i].Find(path); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// Flatten returns this iteration and all its descendants, parents before children
+func (it *Iteration) Flatten() []*Iteration {
+	iterations := []*Iteration{it}
+	for i := range it.Children {
+		iterations = append(iterations, it.Children[i].Flatten()...)
+	}
+
+	return iterations
+}
+
+// WorkItemWebURL turns a work item REST API URL into the URL of the work item page in ADO
+func WorkItemWebURL(apiURL string) string {
+	return strings.Replace(apiURL, "/_apis/wit/workItems/", "/_workitems/edit/", 1)
+}
+
+// WebURL returns the URL of the work item page in ADO, empty when the work item has no URL
+func (wi *WorkItem) WebURL() string {
+	return WorkItemWebURL(wi.URL)
+}
+
 // GetTitle returns the title of the work item
 func (wi *WorkItem) GetTitle() string {
 	if title, ok := wi.Fields["System.Title"].(string); ok {
@@ -118,6 +186,19 @@ func (wi *WorkItem) GetCreatedDate() *time.Time {
 	return nil
 }
 
+// GetClosedDate returns when the work item was closed. Items without a closed date fall back
+// to the last change, which for a closed item is usually its closing.
+func (wi *WorkItem) GetClosedDate() *time.Time {
+	for _, field := range []string{"Microsoft.VSTS.Common.ClosedDate", "System.ChangedDate"} {
+		if value, ok := wi.Fields[field].(string); ok {
+			if t, err := time.Parse(time.RFC3339, value); err == nil {
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
 // GetTags returns the tags as a slice
 func (wi *WorkItem) GetTags() []string {
 	if tags, ok := wi.Fields["System.Tags"].(string); ok && tags != "" {
@@ -127,6 +208,21 @@ func (wi *WorkItem) GetTags() []string {
 	return []string{}
 }
 
+// RetainFields drops every field not present in fields, keeping the
+// work item footprint down to what the active mapping actually reads
+func (wi *WorkItem) RetainFields(fields []string) {
+	keep := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		keep[field] = true
+	}
+
+	for key := range wi.Fields {
+		if !keep[key] {
+			delete(wi.Fields, key)
+		}
+	}
+}
+
 // Helper function to safely get string from map
 func getStringFromMap(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
@@ -475,6 +475,114 @@ func TestWorkItem_GetTags(t *testing.T) {
 	})
 }
 
+func TestWorkItem_GetClosedDate(t *testing.T) {
+	closed := &WorkItem{Fields: map[string]interface{}{
+		"Microsoft.VSTS.Common.ClosedDate": "2019-03-02T10:00:00Z",
+		"System.ChangedDate":               "2020-01-01T00:00:00Z",
+	}}
+	require.NotNil(t, closed.GetClosedDate())
+	assert.Equal(t, time.Date(2019, 3, 2, 10, 0, 0, 0, time.UTC), *closed.GetClosedDate())
+
+	changed := &WorkItem{Fields: map[string]interface{}{"System.ChangedDate": "2020-01-01T00:00:00Z"}}
+	require.NotNil(t, changed.GetClosedDate())
+	assert.Equal(t, 2020, changed.GetClosedDate().Year())
+
+	assert.Nil(t, (&WorkItem{Fields: map[string]interface{}{}}).GetClosedDate())
+}
+
+func TestWorkItem_WebURL(t *testing.T) {
+	workItem := &WorkItem{ID: 42, URL: "https://dev.azure.com/org/project/_apis/wit/workItems/42"}
+	assert.Equal(t, "https://dev.azure.com/org/project/_workitems/edit/42", workItem.WebURL())
+
+	assert.Empty(t, (&WorkItem{ID: 42}).WebURL())
+}
+
+func TestWorkItem_RetainFields(t *testing.T) {
+	t.Run("keeps only requested fields", func(t *testing.T) {
+		workItem := &WorkItem{
+			Fields: map[string]interface{}{
+				"System.Title":          "Title",
+				"System.State":          "Active",
+				"System.History":        "<p>Large history blob</p>",
+				"Custom.UnusedField":    42,
+				"System.BoardColumn":    "Doing",
+				"System.CommentCount":   3,
+				"System.WorkItemType":   "Bug",
+				"System.TeamProject":    "Project",
+				"System.IterationPath":  "Project\\Sprint 1",
+				"System.AuthorizedDate": "2024-01-01T00:00:00Z",
+			},
+		}
+
+		workItem.RetainFields([]string{"System.Title", "System.State", "System.WorkItemType"})
+
+		assert.Equal(t, map[string]interface{}{
+			"System.Title":        "Title",
+			"System.State":        "Active",
+			"System.WorkItemType": "Bug",
+		}, workItem.Fields)
+	})
+
+	t.Run("ignores requested fields that are missing", func(t *testing.T) {
+		workItem := &WorkItem{
+			Fields: map[string]interface{}{
+				"System.Title": "Title",
+			},
+		}
+
+		workItem.RetainFields([]string{"System.Title", "System.Description"})
+
+		assert.Equal(t, map[string]interface{}{"System.Title": "Title"}, workItem.Fields)
+	})
+
+	t.Run("empty projection drops everything", func(t *testing.T) {
+		workItem := &WorkItem{
+			Fields: map[string]interface{}{
+				"System.Title": "Title",
+			},
+		}
+
+		workItem.RetainFields([]string{})
+
+		assert.Empty(t, workItem.Fields)
+	})
+}
+
+func TestIteration_Find(t *testing.T) {
+	root := &Iteration{
+		Name: "Project",
+		Path: "Project",
+		Children: []Iteration{
+			{
+				Name: "Release 1",
+				Path: "Project\\Release 1",
+				Children: []Iteration{
+					{Name: "Sprint 1", Path: "Project\\Release 1\\Sprint 1"},
+					{Name: "Sprint 2", Path: "Project\\Release 1\\Sprint 2"},
+				},
+			},
+		},
+	}
+
+	t.Run("finds nested iteration ignoring case", func(t *testing.T) {
+		found := root.Find("project\\release 1\\sprint 2")
+		require.NotNil(t, found)
+		assert.Equal(t, "Sprint 2", found.Name)
+	})
+
+	t.Run("returns nil for unknown path", func(t *testing.T) {
+		assert.Nil(t, root.Find("Project\\Release 2"))
+	})
+
+	t.Run("flatten lists parents first", func(t *testing.T) {
+		var names []string
+		for _, iteration := range root.Flatten() {
+			names = append(names, iteration.Name)
+		}
+		assert.Equal(t, []string{"Project", "Release 1", "Sprint 1", "Sprint 2"}, names)
+	})
+}
+
 func TestGetStringFromMap(t *testing.T) {
 	t.Run("returns string when key exists and value is string", func(t *testing.T) {
 		m := map[string]interface{}{
@@ -693,3 +801,25 @@ func TestWorkItem_Integration(t *testing.T) {
 		assert.Nil(t, workItem.GetCreatedBy())
 	})
 }
+
+func TestMigrationReport_ComputeBreakdown(t *testing.T) {
+	report := &MigrationReport{
+		Mappings: []MigrationMapping{
+			{AdoWorkItemType: "Bug", Status: "success", TargetState: "open", Labels: []string{"bug", "priority-high"}},
+			{AdoWorkItemType: "Bug", Status: "success", TargetState: "closed", Labels: []string{"bug"}},
+			{AdoWorkItemType: "Bug", Status: "failed", TargetState: "open", Labels: []string{"bug"}, RejectedAssignees: []string{"octocat"}},
+			{AdoWorkItemType: "Task", Status: "skipped"},
+		},
+	}
+
+	report.ComputeBreakdown()
+
+	require.NotNil(t, report.Breakdown)
+	assert.Equal(t, map[string]StatusCounts{
+		"Bug":  {Successful: 2, Failed: 1},
+		"Task": {Skipped: 1},
+	}, report.Breakdown.ByType)
+	assert.Equal(t, map[string]int{"open": 1, "closed": 1}, report.Breakdown.ByState)
+	assert.Equal(t, map[string]int{"bug": 2, "priority-high": 1}, report.Breakdown.ByLabel)
+	assert.Equal(t, map[string]int{"octocat": 1}, report.Breakdown.ByRejectedAssignee)
+}
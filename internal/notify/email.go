@@ -0,0 +1,135 @@
+// Package notify sends the end-of-run summary to people who are not watching the
+// run, e.g. migrations left running overnight on a jump box.
+package notify
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+)
+
+// defaultSMTPPort is the submission port, which upgrades to TLS with STARTTLS
+const defaultSMTPPort = 587
+
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// EmailNotifier sends messages through an SMTP server
+type EmailNotifier struct {
+	config   *config.EmailConfig
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmailNotifier(cfg *config.EmailConfig) *EmailNotifier {
+	return &EmailNotifier{
+		config:   cfg,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send emails subject and the plain text body to the configured recipients.
+// The connection is upgraded with STARTTLS when the server supports it.
+func (n *EmailNotifier) Send(subject, body string, attachments ...Attachment) error {
+	message, err := buildMessage(n.config.From, n.config.To, subject, body, time.Now(), attachments)
+	if err != nil {
+		return err
+	}
+
+	port := n.config.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(n.config.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.SMTPHost)
+	}
+
+	if err := n.sendMail(addr, auth, n.config.From, n.config.To, message); err != nil {
+		return fmt.Errorf("failed to send email through %s: %w", addr, err)
+	}
+
+	return nil
+}
+
+// buildMessage renders a multipart/mixed message with the body as the first part
+func buildMessage(from string, to []string, subject, body string, date time.Time, attachments []Attachment) ([]byte, error) {
+	var message bytes.Buffer
+	writer := multipart.NewWriter(&message)
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", writer.Boundary()),
+	}
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write email body: %w", err)
+	}
+	if err := writeBase64(part, []byte(body)); err != nil {
+		return nil, fmt.Errorf("failed to write email body: %w", err)
+	}
+
+	for _, attachment := range attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach %s: %w", attachment.Name, err)
+		}
+		if err := writeBase64(part, attachment.Data); err != nil {
+			return nil, fmt.Errorf("failed to attach %s: %w", attachment.Name, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish email: %w", err)
+	}
+
+	return message.Bytes(), nil
+}
+
+// writeBase64 encodes data in 76 character lines as required for MIME
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
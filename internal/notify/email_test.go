@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlucaspains/adowi2gh/internal/config"
+)
+
+func TestEmailNotifier(t *testing.T) {
+	notifier := NewEmailNotifier(&config.EmailConfig{
+		SMTPHost: "smtp.example.com",
+		Username: "user",
+		Password: "secret",
+		From:     "migration@example.com",
+		To:       []string{"a@example.com", "b@example.com"},
+	})
+
+	var addr string
+	var auth smtp.Auth
+	var message []byte
+	notifier.sendMail = func(a string, au smtp.Auth, from string, to []string, msg []byte) error {
+		addr, auth, message = a, au, msg
+		assert.Equal(t, "migration@example.com", from)
+		assert.Equal(t, []string{"a@example.com", "b@example.com"}, to)
+		return nil
+	}
+
+	err := notifier.Send("Migration finished ✓", "Successful  2\n", Attachment{Name: "report.json", ContentType: "application/json", Data: []byte(`{"successful_count":2}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.NotNil(t, auth)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(message)))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Migration finished ✓", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var parts []string
+	var filenames []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "base64", part.Header.Get("Content-Transfer-Encoding"))
+		data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		require.NoError(t, err)
+		parts = append(parts, string(data))
+		filenames = append(filenames, part.FileName())
+	}
+
+	assert.Equal(t, []string{"Successful  2\n", `{"successful_count":2}`}, parts)
+	assert.Equal(t, []string{"", "report.json"}, filenames)
+}
@@ -0,0 +1,143 @@
+// Package reports manages the migration report files kept in the reports directory
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/jlucaspains/adowi2gh/internal/filename"
+	"github.com/jlucaspains/adowi2gh/internal/models"
+)
+
+// DefaultRunName prefixes report file names when no run name is configured
+const DefaultRunName = "migration_report"
+
+// fileNamePattern matches the names FileName builds: the run name, the start time and
+// an optional dry run suffix
+var fileNamePattern = regexp.MustCompile(`^(.+)_\d{8}_\d{6}(_dryrun)?\.json$`)
+
+// Entry describes a report file found in the reports directory
+type Entry struct {
+	Name    string
+	Path    string
+	RunName string // Sanitized run name the report was written under
+	ModTime time.Time
+	Report  *models.MigrationReport // nil when the file could not be parsed
+}
+
+// FileName builds the report file name for a run. Dry runs are suffixed so
+// they are easy to tell apart from real migrations. The run name is sanitized
+// so the file can be created on Windows.
+func FileName(runName string, start time.Time, dryRun bool) string {
+	name := fmt.Sprintf("%s_%s", fileRunName(runName), start.Format("20060102_150405"))
+	if dryRun {
+		name += "_dryrun"
+	}
+
+	return name + ".json"
+}
+
+// fileRunName returns the run name as it appears in report file names
+func fileRunName(runName string) string {
+	if runName == "" {
+		runName = DefaultRunName
+	}
+
+	return filename.Sanitize(runName)
+}
+
+// List returns the reports in dir, newest first. Only files named like FileName builds
+// them are reports, anything else in dir is left out. A missing directory has no reports.
+func List(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reports directory: %w", err)
+	}
+
+	entries := []Entry{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat report %s: %w", file.Name(), err)
+		}
+
+		path := filepath.Join(dir, file.Name())
+		entries = append(entries, Entry{
+			Name:    file.Name(),
+			Path:    path,
+			RunName: match[1],
+			ModTime: info.ModTime(),
+			Report:  readReport(path),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.After(entries[j].ModTime)
+	})
+
+	return entries, nil
+}
+
+// Prune deletes all but the newest keep reports of runName in dir and returns the removed
+// paths. Reports of other runs and files that aren't reports are never touched.
+// A keep value of zero or less keeps every report.
+func Prune(dir, runName string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	entries, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	runName = fileRunName(runName)
+	kept := 0
+	var removed []string
+	for _, entry := range entries {
+		if entry.RunName != runName {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+
+		if err := os.Remove(entry.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove report %s: %w", entry.Name, err)
+		}
+		removed = append(removed, entry.Path)
+	}
+
+	return removed, nil
+}
+
+func readReport(path string) *models.MigrationReport {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	report := &models.MigrationReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil
+	}
+
+	return report
+}
@@ -0,0 +1,112 @@
+package reports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReport(t *testing.T, dir, name, content string, age time.Duration) {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestFileName(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "migration_report_20250115_103000.json", FileName("", start, false))
+	assert.Equal(t, "wave1_20250115_103000.json", FileName("wave1", start, false))
+	assert.Equal(t, "wave1_20250115_103000_dryrun.json", FileName("wave1", start, true))
+	assert.Equal(t, "Sprint 4_ web_ui_20250115_103000.json", FileName("Sprint 4: web/ui", start, false))
+}
+
+func TestList(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		entries, err := List(filepath.Join(t.TempDir(), "missing"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("newest first with parsed summaries", func(t *testing.T) {
+		dir := t.TempDir()
+		writeReport(t, dir, "wave1_20250115_080000.json", `{"total_work_items": 10, "successful_count": 9}`, 2*time.Hour)
+		writeReport(t, dir, "wave1_20250115_090000_dryrun.json", `{"total_work_items": 5, "dry_run": true}`, time.Hour)
+		writeReport(t, dir, "migration_report_20250114_170000.json", `{not json`, 3*time.Hour)
+		writeReport(t, dir, "notes.txt", `ignored`, 0)
+		writeReport(t, dir, "migration_checkpoint.json", `{}`, 0)
+		writeReport(t, dir, "wave1_latest.json", `{}`, 0)
+
+		entries, err := List(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		assert.Equal(t, "wave1_20250115_090000_dryrun.json", entries[0].Name)
+		assert.Equal(t, "wave1", entries[0].RunName)
+		require.NotNil(t, entries[0].Report)
+		assert.Equal(t, 5, entries[0].Report.TotalWorkItems)
+		assert.True(t, entries[0].Report.DryRun)
+
+		assert.Equal(t, "wave1_20250115_080000.json", entries[1].Name)
+		require.NotNil(t, entries[1].Report)
+		assert.Equal(t, 9, entries[1].Report.SuccessfulCount)
+
+		assert.Equal(t, "migration_report_20250114_170000.json", entries[2].Name)
+		assert.Equal(t, "migration_report", entries[2].RunName)
+		assert.Nil(t, entries[2].Report)
+	})
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	writeReport(t, dir, "wave1_20250115_080000.json", `{}`, 4*time.Hour)
+	writeReport(t, dir, "wave1_20250115_090000_dryrun.json", `{}`, 3*time.Hour)
+	writeReport(t, dir, "wave1_20250115_100000.json", `{}`, 2*time.Hour)
+	writeReport(t, dir, "wave2_20250114_100000.json", `{}`, 5*time.Hour)
+	writeReport(t, dir, "migration_checkpoint.json", `{}`, 6*time.Hour)
+	writeReport(t, dir, "settings.json", `{}`, 6*time.Hour)
+
+	removed, err := Prune(dir, "wave1", 0)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	removed, err = Prune(dir, "wave1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "wave1_20250115_080000.json")}, removed)
+
+	entries, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "wave1_20250115_100000.json", entries[0].Name)
+	assert.Equal(t, "wave1_20250115_090000_dryrun.json", entries[1].Name)
+	assert.Equal(t, "wave2_20250114_100000.json", entries[2].Name)
+
+	// Files that aren't reports survive any retention
+	for _, name := range []string{"migration_checkpoint.json", "settings.json"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err)
+	}
+
+	t.Run("default and sanitized run names", func(t *testing.T) {
+		dir := t.TempDir()
+		start := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+		writeReport(t, dir, FileName("", start, false), `{}`, 2*time.Hour)
+		writeReport(t, dir, FileName("", start.Add(time.Hour), false), `{}`, time.Hour)
+		writeReport(t, dir, FileName("Sprint 4: web/ui", start, false), `{}`, 3*time.Hour)
+		writeReport(t, dir, FileName("Sprint 4: web/ui", start.Add(time.Hour), false), `{}`, time.Hour)
+
+		removed, err := Prune(dir, "", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "migration_report_20250115_103000.json")}, removed)
+
+		removed, err = Prune(dir, "Sprint 4: web/ui", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, "Sprint 4_ web_ui_20250115_103000.json")}, removed)
+	})
+}